@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	FlagRefresh  bool
+	FlagCacheTTL time.Duration
+)
+
+// background tracks work detached from the command, such as cache refreshes.
+// It does not delay exiting, the work is abandoned and left to the next run if the command completes first.
+var background sync.WaitGroup
+
+// cacheRefreshTimeout bounds a background refresh
+const cacheRefreshTimeout = 10 * time.Second
+
+// userCacheDir returns the base directory of the cache, it is replaced in tests
+var userCacheDir = os.UserCacheDir
+
+type cacheEntry struct {
+	Key       string    `json:"key"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Items     []string  `json:"items"`
+}
+
+// getCachedLogGroups returns the log groups from the cache if any, otherwise retrieves and caches them.
+// Cached log groups older than the TTL are returned as is while being refreshed in the background,
+// which the cache is only updated by once it completes.
+func getCachedLogGroups(ctx context.Context, client LogsAPI) ([]string, error) {
+	if FlagCacheTTL <= 0 {
+		return getLogGroups(ctx, client)
+	}
+
+	path, key, err := cachePath(ctx, "log-groups")
+	if err != nil {
+		// the cache is best effort
+		return getLogGroups(ctx, client)
+	}
+
+	if !FlagRefresh {
+		if entry, err := readCache(path); err == nil && entry.Key == key {
+			if time.Since(entry.UpdatedAt) > FlagCacheTTL {
+				background.Add(1)
+				go func() {
+					defer background.Done()
+
+					// detached from the command, which may complete before the refresh does
+					ctx, cancel := context.WithTimeout(context.Background(), cacheRefreshTimeout)
+					defer cancel()
+
+					_, _ = refreshLogGroups(ctx, client, path, key)
+				}()
+			}
+
+			return entry.Items, nil
+		}
+	}
+
+	return refreshLogGroups(ctx, client, path, key)
+}
+
+// refreshLogGroups retrieves the log groups and writes them to the cache
+func refreshLogGroups(ctx context.Context, client LogsAPI, path, key string) ([]string, error) {
+	lg, err := getLogGroups(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cache is best effort
+	_ = writeCache(path, cacheEntry{
+		Key:       key,
+		UpdatedAt: time.Now(),
+		Items:     lg,
+	})
+
+	return lg, nil
+}
+
+// cachePath returns the cache file of the listing, which is specific to the profile, region, role and endpoint
+func cachePath(ctx context.Context, name string) (string, string, error) {
+	dir, err := userCacheDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	region, err := configRegion(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", profileOrDefault(), region, FlagRoleARN, FlagEndpointURL)
+	file := fmt.Sprintf("%s-%x.json", name, sha256.Sum256([]byte(key)))
+
+	return filepath.Join(dir, "cwlr", file), key, nil
+}
+
+// configRegions holds the resolved region by profile and region flag, as loading the config reads the shared files
+var configRegions sync.Map
+
+// configRegion returns the region the logs are accessed in, resolved once for the profile and region flag
+func configRegion(ctx context.Context) (string, error) {
+	id := profileOrDefault() + "|" + FlagRegion
+	if v, ok := configRegions.Load(id); ok {
+		return v.(string), nil
+	}
+
+	cfg, err := loadConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	configRegions.Store(id, cfg.Region)
+
+	return cfg.Region, nil
+}
+
+func readCache(path string) (cacheEntry, error) {
+	var entry cacheEntry
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+
+	err = json.Unmarshal(b, &entry)
+	return entry, err
+}
+
+// writeCache replaces the cache file atomically, so concurrent readers never see a partial file
+func writeCache(path string, entry cacheEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
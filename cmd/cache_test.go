@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetCachedLogGroups(t *testing.T) {
+	useTempCacheDir(t)
+
+	origTTL, origRefresh := FlagCacheTTL, FlagRefresh
+	t.Cleanup(func() {
+		FlagCacheTTL, FlagRefresh = origTTL, origRefresh
+	})
+	FlagCacheTTL = time.Hour
+
+	ctx := context.Background()
+
+	fake := newFakeLogs(1)
+	fake.AddLogGroup("/app/api")
+
+	get := func() string {
+		t.Helper()
+
+		lg, err := getCachedLogGroups(ctx, fake)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return strings.Join(lg, ",")
+	}
+
+	if got := get(); got != "/app/api" {
+		t.Fatalf("got %q on first retrieval", got)
+	}
+
+	// served from the cache within the ttl
+	fake.AddLogGroup("/app/web")
+	if got := get(); got != "/app/api" {
+		t.Errorf("got %q, want cached log groups", got)
+	}
+
+	// forced reload
+	FlagRefresh = true
+	if got := get(); got != "/app/api,/app/web" {
+		t.Errorf("got %q, want reloaded log groups", got)
+	}
+	FlagRefresh = false
+
+	// expired entries are served while being refreshed in the background
+	FlagCacheTTL = time.Nanosecond
+	fake.AddLogGroup("/app/worker")
+	if got := get(); got != "/app/api,/app/web" {
+		t.Errorf("got %q, want stale log groups", got)
+	}
+
+	background.Wait()
+
+	FlagCacheTTL = time.Hour
+	if got := get(); got != "/app/api,/app/web,/app/worker" {
+		t.Errorf("got %q, want refreshed log groups", got)
+	}
+}
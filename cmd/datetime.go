@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTimeHint describes the accepted date time expressions
+const dateTimeHint = "e.g. now, 15m, 2h ago, yesterday 09:00, 2022-10-01 12:00:00, RFC3339 or unix epoch"
+
+var errInvalidDateTime = errors.New("invalid date time, " + dateTimeHint)
+
+// layouts of absolute date times, values without an offset are in the location of now
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// layouts of a time of day following today or yesterday
+var timeOfDayLayouts = []string{
+	"15:04:05",
+	"15:04",
+}
+
+// minEpochDigits is the minimum number of digits of a unix epoch
+const minEpochDigits = 9
+
+var (
+	relativeRe = regexp.MustCompile(`^-?(\d+)\s*([a-z]+)(?:\s+ago)?$`)
+	epochRe    = regexp.MustCompile(`^\d+$`)
+)
+
+// durations of the units accepted in relative expressions
+var relativeUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// parseDateTime parses an absolute or relative date time expression, relative to now:
+//
+//	now, today, yesterday          - current time, start of today or yesterday
+//	today 09:00, yesterday 09:00   - time of day, with optional seconds
+//	15m, 2h ago, 3 days ago        - duration before now
+//	1664582400, 1664582400000      - unix epoch in seconds or milliseconds, at least 9 digits
+//	2022-10-01, 2022-10-01 12:00   - date with optional time, in the location of now
+//	2022-10-01T12:00:00+08:00      - RFC3339
+func parseDateTime(s string, now time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+
+	switch s {
+	case "":
+		return time.Time{}, errInvalidDateTime
+	case "now":
+		return now, nil
+	}
+
+	// today or yesterday, with optional time of day
+	for day, offset := range map[string]int{"today": 0, "yesterday": -1} {
+		if s != day && !strings.HasPrefix(s, day+" ") {
+			continue
+		}
+
+		y, m, d := now.Date()
+		midnight := time.Date(y, m, d+offset, 0, 0, 0, 0, now.Location())
+
+		tod := strings.TrimSpace(strings.TrimPrefix(s, day))
+		if tod == "" {
+			return midnight, nil
+		}
+
+		// the wall clock time, which is not a fixed duration after midnight on daylight saving changes
+		for _, layout := range timeOfDayLayouts {
+			if t, err := time.Parse(layout, tod); err == nil {
+				return time.Date(y, m, d+offset, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+			}
+		}
+
+		return time.Time{}, errInvalidDateTime
+	}
+
+	// unix epoch, milliseconds when too large to be seconds.
+	// Shorter numbers are rejected, as they are more likely a year or a time than a date before 1973.
+	if epochRe.MatchString(s) {
+		if len(s) < minEpochDigits {
+			return time.Time{}, errInvalidDateTime
+		}
+
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, errInvalidDateTime
+		}
+
+		if len(s) > 10 {
+			return time.UnixMilli(n).In(now.Location()), nil
+		}
+
+		return time.Unix(n, 0).In(now.Location()), nil
+	}
+
+	// duration before now
+	if m := relativeRe.FindStringSubmatch(s); m != nil {
+		unit, ok := relativeUnits[m[2]]
+		if !ok {
+			return time.Time{}, errInvalidDateTime
+		}
+
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, errInvalidDateTime
+		}
+
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+
+	// absolute date time, layouts are case sensitive
+	s = strings.ToUpper(s)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errInvalidDateTime
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateTime(t *testing.T) {
+	sgt := time.FixedZone("SGT", 8*60*60)
+	now := time.Date(2022, 10, 5, 13, 30, 0, 0, sgt)
+
+	for _, tc := range []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "now", want: now},
+		{input: "15m", want: now.Add(-15 * time.Minute)},
+		{input: "2h ago", want: now.Add(-2 * time.Hour)},
+		{input: "3 days ago", want: now.Add(-72 * time.Hour)},
+		{input: "today", want: time.Date(2022, 10, 5, 0, 0, 0, 0, sgt)},
+		{input: "Yesterday 09:00", want: time.Date(2022, 10, 4, 9, 0, 0, 0, sgt)},
+		{input: "1664582400", want: time.Unix(1664582400, 0)},
+		{input: "1664582400123", want: time.UnixMilli(1664582400123)},
+		{input: "2022-10-01", want: time.Date(2022, 10, 1, 0, 0, 0, 0, sgt)},
+		{input: "2022-10-01 12:00", want: time.Date(2022, 10, 1, 12, 0, 0, 0, sgt)},
+		{input: "2022-10-01T12:00:00Z", want: time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)},
+	} {
+		got, err := parseDateTime(tc.input, now)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.input, err)
+			continue
+		}
+
+		if !got.Equal(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{"", "soon", "5 fortnights", "yesterday noon", "2022", "930", "0"} {
+		if _, err := parseDateTime(input, now); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestParseDateTimeDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// daylight saving time starts at 2am on 2022-03-13, the day is 23 hours long
+	now := time.Date(2022, 3, 13, 18, 0, 0, 0, loc)
+	for _, tc := range []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "today 09:00", want: time.Date(2022, 3, 13, 9, 0, 0, 0, loc)},
+		{input: "yesterday 09:00", want: time.Date(2022, 3, 12, 9, 0, 0, 0, loc)},
+	} {
+		got, err := parseDateTime(tc.input, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !got.Equal(tc.want) {
+			t.Errorf("%q: got %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
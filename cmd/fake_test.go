@@ -0,0 +1,467 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// fakeLogs is an in-memory implementation of LogsAPI.
+// Every operation returns at most pageSize items per call, along with pagination tokens.
+type fakeLogs struct {
+	mu       sync.Mutex
+	pageSize int
+
+	groups  []string
+	streams map[string][]string
+	events  []fakeEvent
+
+	queries map[string]*cloudwatchlogs.StartQueryInput
+	stopped []string
+	// running keeps queries running instead of completing them
+	running bool
+
+	// calls is the number of calls by operation
+	calls map[string]int
+}
+
+type fakeEvent struct {
+	id        string
+	group     string
+	stream    string
+	timestamp int64
+	message   string
+}
+
+var _ LogsAPI = (*fakeLogs)(nil)
+
+func newFakeLogs(pageSize int) *fakeLogs {
+	return &fakeLogs{
+		pageSize: pageSize,
+		streams:  make(map[string][]string),
+		queries:  make(map[string]*cloudwatchlogs.StartQueryInput),
+		calls:    make(map[string]int),
+	}
+}
+
+// AddLogGroup creates the log group if it does not exist
+func (f *fakeLogs) AddLogGroup(group string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addLogGroup(group)
+}
+
+func (f *fakeLogs) addLogGroup(group string) {
+	for _, it := range f.groups {
+		if it == group {
+			return
+		}
+	}
+
+	f.groups = append(f.groups, group)
+	sort.Strings(f.groups)
+}
+
+// AddEvent appends an event to the log stream, creating the log group and stream if they do not exist
+func (f *fakeLogs) AddEvent(group, stream string, timestamp int64, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addLogGroup(group)
+
+	var exists bool
+	for _, it := range f.streams[group] {
+		exists = exists || it == stream
+	}
+	if !exists {
+		f.streams[group] = append(f.streams[group], stream)
+	}
+
+	f.events = append(f.events, fakeEvent{
+		id:        strconv.Itoa(len(f.events)),
+		group:     group,
+		stream:    stream,
+		timestamp: timestamp,
+		message:   message,
+	})
+
+	sort.SliceStable(f.events, func(i, j int) bool {
+		return f.events[i].timestamp < f.events[j].timestamp
+	})
+}
+
+type fakeEvents []fakeEvent
+
+// filter returns the events in timestamp order matching the predicate
+func (f fakeEvents) filter(fn func(fakeEvent) bool) []fakeEvent {
+	var out []fakeEvent
+	for _, it := range f {
+		if fn(it) {
+			out = append(out, it)
+		}
+	}
+
+	return out
+}
+
+// page returns the bounds of the page starting at the token offset, and the token of the next page if any
+func page(token *string, total, size int) (int, int, *string, error) {
+	var start int
+	if token != nil {
+		n, err := strconv.Atoi(*token)
+		if err != nil {
+			return 0, 0, nil, errors.New("invalid next token")
+		}
+		start = n
+	}
+
+	end := start + size
+	if end >= total {
+		return start, total, nil, nil
+	}
+
+	return start, end, aws.String(strconv.Itoa(end)), nil
+}
+
+func (f *fakeLogs) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["DescribeLogGroups"]++
+
+	var groups []string
+	for _, it := range f.groups {
+		if strings.HasPrefix(it, aws.ToString(params.LogGroupNamePrefix)) {
+			groups = append(groups, it)
+		}
+	}
+
+	start, end, next, err := page(params.NextToken, len(groups), f.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{NextToken: next}
+	for _, it := range groups[start:end] {
+		out.LogGroups = append(out.LogGroups, types.LogGroup{LogGroupName: aws.String(it)})
+	}
+
+	return out, nil
+}
+
+func (f *fakeLogs) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["DescribeLogStreams"]++
+
+	prefix := aws.ToString(params.LogStreamNamePrefix)
+	if prefix != "" && params.OrderBy == types.OrderByLastEventTime {
+		return nil, errors.New("InvalidParameterException: cannot order by LastEventTime with a logStreamNamePrefix")
+	}
+
+	var streams []types.LogStream
+	for _, name := range f.streams[aws.ToString(params.LogGroupName)] {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ls := types.LogStream{LogStreamName: aws.String(name)}
+		for _, it := range f.events {
+			if it.group == aws.ToString(params.LogGroupName) && it.stream == name {
+				ls.LastEventTimestamp = aws.Int64(it.timestamp)
+			}
+		}
+
+		streams = append(streams, ls)
+	}
+
+	sort.SliceStable(streams, func(i, j int) bool {
+		a, b := streams[i], streams[j]
+		if aws.ToBool(params.Descending) {
+			a, b = b, a
+		}
+
+		if params.OrderBy == types.OrderByLastEventTime {
+			return aws.ToInt64(a.LastEventTimestamp) < aws.ToInt64(b.LastEventTimestamp)
+		}
+
+		return aws.ToString(a.LogStreamName) < aws.ToString(b.LogStreamName)
+	})
+
+	start, end, next, err := page(params.NextToken, len(streams), f.pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudwatchlogs.DescribeLogStreamsOutput{
+		LogStreams: streams[start:end],
+		NextToken:  next,
+	}, nil
+}
+
+// GetLogEvents pages forward with "f/<index>" tokens and backward with "b/<index>" tokens.
+// Like CloudWatch Logs, the same forward token is returned once the end of the stream is reached,
+// and a forward token must be used with StartFromHead.
+func (f *fakeLogs) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetLogEvents"]++
+
+	events := fakeEvents(f.events).filter(func(it fakeEvent) bool {
+		return it.group == aws.ToString(params.LogGroupName) &&
+			it.stream == aws.ToString(params.LogStreamName) &&
+			inRange(it.timestamp, params.StartTime, params.EndTime)
+	})
+
+	size := f.pageSize
+	if params.Limit != nil && int(*params.Limit) < size {
+		size = int(*params.Limit)
+	}
+
+	var start, end int
+	switch token := aws.ToString(params.NextToken); {
+	case strings.HasPrefix(token, "f/"):
+		if !aws.ToBool(params.StartFromHead) {
+			return nil, errors.New("forward token requires StartFromHead")
+		}
+
+		n, err := strconv.Atoi(token[2:])
+		if err != nil {
+			return nil, errors.New("invalid next token")
+		}
+		start, end = n, n+size
+		if end > len(events) {
+			end = len(events)
+		}
+	case strings.HasPrefix(token, "b/"):
+		n, err := strconv.Atoi(token[2:])
+		if err != nil {
+			return nil, errors.New("invalid next token")
+		}
+		start, end = n-size, n
+		if start < 0 {
+			start = 0
+		}
+	case token != "":
+		return nil, errors.New("invalid next token")
+	case aws.ToBool(params.StartFromHead):
+		start, end = 0, size
+		if end > len(events) {
+			end = len(events)
+		}
+	default:
+		start, end = len(events)-size, len(events)
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	out := &cloudwatchlogs.GetLogEventsOutput{
+		NextForwardToken:  aws.String(fmt.Sprintf("f/%d", end)),
+		NextBackwardToken: aws.String(fmt.Sprintf("b/%d", start)),
+	}
+	for _, it := range events[start:end] {
+		out.Events = append(out.Events, types.OutputLogEvent{
+			Timestamp:     aws.Int64(it.timestamp),
+			IngestionTime: aws.Int64(it.timestamp),
+			Message:       aws.String(it.message),
+		})
+	}
+
+	return out, nil
+}
+
+func (f *fakeLogs) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["FilterLogEvents"]++
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pattern := aws.ToString(params.FilterPattern)
+	if strings.HasPrefix(strings.TrimSpace(pattern), "{") {
+		return nil, errors.New("fake: JSON filter patterns are not supported")
+	}
+
+	events := fakeEvents(f.events).filter(func(it fakeEvent) bool {
+		if it.group != aws.ToString(params.LogGroupName) ||
+			!strings.HasPrefix(it.stream, aws.ToString(params.LogStreamNamePrefix)) ||
+			!inRange(it.timestamp, params.StartTime, params.EndTime) {
+			return false
+		}
+
+		if len(params.LogStreamNames) > 0 {
+			var ok bool
+			for _, name := range params.LogStreamNames {
+				ok = ok || it.stream == name
+			}
+			if !ok {
+				return false
+			}
+		}
+
+		return matchFakePattern(pattern, it.message)
+	})
+
+	size := f.pageSize
+	if params.Limit != nil && int(*params.Limit) < size {
+		size = int(*params.Limit)
+	}
+
+	start, end, next, err := page(params.NextToken, len(events), size)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &cloudwatchlogs.FilterLogEventsOutput{NextToken: next}
+	for _, it := range events[start:end] {
+		out.Events = append(out.Events, types.FilteredLogEvent{
+			EventId:       aws.String(it.id),
+			LogStreamName: aws.String(it.stream),
+			Timestamp:     aws.Int64(it.timestamp),
+			IngestionTime: aws.Int64(it.timestamp),
+			Message:       aws.String(it.message),
+		})
+	}
+
+	return out, nil
+}
+
+// matchFakePattern supports a subset of the filter pattern syntax for unstructured logs:
+// all terms must match, "quoted phrases" are a single term, -term excludes and ?term matches any of the ?terms
+func matchFakePattern(pattern, message string) bool {
+	var optional []string
+	for _, term := range splitTerms(pattern) {
+		switch {
+		case strings.HasPrefix(term, "-"):
+			if strings.Contains(message, term[1:]) {
+				return false
+			}
+		case strings.HasPrefix(term, "?"):
+			optional = append(optional, term[1:])
+		default:
+			if !strings.Contains(message, term) {
+				return false
+			}
+		}
+	}
+
+	if len(optional) == 0 {
+		return true
+	}
+
+	for _, term := range optional {
+		if strings.Contains(message, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitTerms splits the pattern by spaces, keeping quoted phrases together without the quotes
+func splitTerms(pattern string) []string {
+	var terms []string
+
+	var sb strings.Builder
+	var quoted bool
+	for _, r := range pattern {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if sb.Len() > 0 {
+				terms = append(terms, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		terms = append(terms, sb.String())
+	}
+
+	return terms
+}
+
+func inRange(ts int64, start, end *int64) bool {
+	return (start == nil || ts >= *start) && (end == nil || ts <= *end)
+}
+
+func (f *fakeLogs) StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["StartQuery"]++
+
+	id := fmt.Sprintf("query-%d", len(f.queries))
+	f.queries[id] = params
+
+	return &cloudwatchlogs.StartQueryOutput{QueryId: aws.String(id)}, nil
+}
+
+// GetQueryResults completes immediately unless running is set, returning the events of the log groups
+// within the time range regardless of the query string
+func (f *fakeLogs) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["GetQueryResults"]++
+
+	q, ok := f.queries[aws.ToString(params.QueryId)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException: query not found")
+	}
+
+	if f.running {
+		return &cloudwatchlogs.GetQueryResultsOutput{Status: types.QueryStatusRunning}, nil
+	}
+
+	groups := make(map[string]bool)
+	for _, it := range q.LogGroupNames {
+		groups[it] = true
+	}
+
+	start, end := aws.ToInt64(q.StartTime)*1000, aws.ToInt64(q.EndTime)*1000+999
+	events := fakeEvents(f.events).filter(func(it fakeEvent) bool {
+		return groups[it.group] && inRange(it.timestamp, &start, &end)
+	})
+
+	out := &cloudwatchlogs.GetQueryResultsOutput{Status: types.QueryStatusComplete}
+	for _, it := range events {
+		out.Results = append(out.Results, []types.ResultField{
+			{Field: aws.String("@timestamp"), Value: aws.String(time.UnixMilli(it.timestamp).UTC().Format(insightsTimeLayout))},
+			{Field: aws.String("@message"), Value: aws.String(it.message)},
+			{Field: aws.String("@ptr"), Value: aws.String(it.id)},
+		})
+	}
+
+	return out, nil
+}
+
+func (f *fakeLogs) StopQuery(ctx context.Context, params *cloudwatchlogs.StopQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StopQueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls["StopQuery"]++
+
+	f.stopped = append(f.stopped, aws.ToString(params.QueryId))
+
+	return &cloudwatchlogs.StopQueryOutput{Success: true}, nil
+}
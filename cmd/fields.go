@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// validateFields returns an error if any of the dot paths is malformed
+func validateFields(fields []string) error {
+	for _, it := range fields {
+		for _, key := range strings.Split(it, ".") {
+			if key == "" {
+				return fmt.Errorf("invalid field %q", it)
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractFields returns the values at the dot paths of a JSON object message, nil for the missing ones.
+// Returns false if there are no fields, without parsing the message, or if the message is not a JSON object.
+func extractFields(msg string, fields []string) ([]interface{}, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	obj, ok := parseJSONObject(msg)
+	if !ok {
+		return nil, false
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, it := range fields {
+		values[i], _ = lookupJSONPath(obj, it)
+	}
+
+	return values, true
+}
+
+// fieldString returns the value as displayed in a column, strings are not quoted
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// minColumnWidth is the initial width of the columns whose field name is shorter
+const minColumnWidth = 6
+
+// columns formats values as columns, widened to the widest value displayed so far as output is streamed.
+// The header of the field names is repeated whenever the columns are widened, so that every row is aligned
+// with the header above it.
+type columns struct {
+	names  []string
+	widths []int
+}
+
+func newColumns(fields []string) *columns {
+	c := &columns{names: fields, widths: make([]int, len(fields))}
+	for i, it := range fields {
+		c.widths[i] = minColumnWidth
+		if len(it) > c.widths[i] {
+			c.widths[i] = len(it)
+		}
+	}
+
+	return c
+}
+
+// header returns the field names aligned to the columns, without a newline
+func (c *columns) header() string {
+	return c.row(c.names)
+}
+
+// format returns the values as a row, and whether the columns are widened to fit them,
+// in which case the rows displayed before are not aligned with it
+func (c *columns) format(values []interface{}) (string, bool) {
+	var widened bool
+
+	cells := make([]string, len(values))
+	for i, it := range values {
+		cells[i] = fieldString(it)
+		if cells[i] == "" {
+			cells[i] = "-"
+		}
+
+		// the last column is not padded, hence its width does not matter
+		if i < len(values)-1 && len(cells[i]) > c.widths[i] {
+			c.widths[i] = len(cells[i])
+			widened = true
+		}
+	}
+
+	return c.row(cells) + "\n", widened
+}
+
+func (c *columns) row(cells []string) string {
+	var sb strings.Builder
+	for i, s := range cells {
+		// the last column is not padded to avoid trailing spaces
+		if i == len(cells)-1 {
+			sb.WriteString(s)
+			break
+		}
+
+		sb.WriteString(s + strings.Repeat(" ", c.widths[i]-len(s)+2))
+	}
+
+	return sb.String()
+}
+
+// jsonFields are extracted fields which marshal into a JSON object in the order of the fields
+type jsonFields struct {
+	names  []string
+	values []interface{}
+}
+
+func (f jsonFields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString("{")
+	for i, name := range f.names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		k, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(f.values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteString(":")
+		buf.Write(v)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
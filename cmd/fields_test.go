@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestReadFields(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, `{"level":"info","msg":"started","req":{"id":"a1"}}`+"\n")
+	fake.AddEvent("/app/api", "s1", 2000, "plain text\n")
+	fake.AddEvent("/app/api", "s1", 3000, `{"level":"error","msg":"failed","req":{"id":"b22"},"n":1.50}`+"\n")
+
+	for _, tc := range []struct {
+		output string
+		fields string
+		want   string
+	}{
+		// the field names are above the first row
+		{
+			output: "text",
+			fields: "level,req.id,msg",
+			want: "                      level   req.id  msg\n" +
+				"1970-01-01T00:00:01Z: info    a1      started\n" +
+				"1970-01-01T00:00:02Z: plain text\n" +
+				"1970-01-01T00:00:03Z: error   b22     failed\n",
+		},
+		{
+			output: "raw",
+			fields: "level,req.id,msg",
+			want:   "level   req.id  msg\ninfo    a1      started\nplain text\nerror   b22     failed\n",
+		},
+		{
+			output: "ndjson",
+			fields: "msg,n",
+			want: `{"timestamp":"1970-01-01T00:00:01.000Z","ingestionTime":"1970-01-01T00:00:01.000Z","logGroupName":"/app/api","logStreamName":"s1","fields":{"msg":"started","n":null}}` + "\n" +
+				`{"timestamp":"1970-01-01T00:00:02.000Z","ingestionTime":"1970-01-01T00:00:02.000Z","logGroupName":"/app/api","logStreamName":"s1","message":"plain text\n"}` + "\n" +
+				`{"timestamp":"1970-01-01T00:00:03.000Z","ingestionTime":"1970-01-01T00:00:03.000Z","logGroupName":"/app/api","logStreamName":"s1","fields":{"msg":"failed","n":1.50}}` + "\n",
+		},
+		{
+			output: "csv",
+			fields: "level,req",
+			want: "timestamp,ingestion_time,log_group_name,log_stream_name,event_id,message,level,req\n" +
+				"1970-01-01T00:00:01.000Z,1970-01-01T00:00:01.000Z,/app/api,s1,,,info,\"{\"\"id\"\":\"\"a1\"\"}\"\n" +
+				"1970-01-01T00:00:02.000Z,1970-01-01T00:00:02.000Z,/app/api,s1,,plain text,,\n" +
+				"1970-01-01T00:00:03.000Z,1970-01-01T00:00:03.000Z,/app/api,s1,,,error,\"{\"\"id\"\":\"\"b22\"\"}\"\n",
+		},
+	} {
+		stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--tz", "UTC", "--output", tc.output, "--fields", tc.fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.output, stdout, tc.want)
+		}
+	}
+
+	if _, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--fields", "req..id"); err == nil {
+		t.Error("expected error for invalid field")
+	}
+}
+
+func TestReadFieldsWiden(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, `{"level":"info","msg":"started","request_id":"r1"}`)
+	fake.AddEvent("/app/api", "s1", 2000, `{"level":"error","msg":"x","request_id":"r2"}`)
+	fake.AddEvent("/app/api", "s1", 3000, `{"level":"warn","msg":"connection refused","request_id":"r3"}`)
+	fake.AddEvent("/app/api", "s1", 4000, `{"level":"info","msg":"done","request_id":"r4"}`)
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--fields", "level,msg,request_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the header is repeated once the middle column is widened, the rows below it are aligned with it
+	want := "level   msg      request_id\n" +
+		"info    started  r1\n" +
+		"error   x        r2\n" +
+		"level   msg                 request_id\n" +
+		"warn    connection refused  r3\n" +
+		"info    done                r4\n"
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// highlight colors the matches of a regular expression in messages
+type highlight struct {
+	re   *regexp.Regexp
+	role string
+	// group is the submatch colored, the whole match if zero
+	group int
+}
+
+// Highlight returns s in the color of the base role, with the matches of the highlights in the color of theirs.
+// Where matches overlap, the earlier highlight takes precedence.
+func (t *Theme) Highlight(s, base string, hs []highlight) string {
+	if len(hs) == 0 {
+		return t.Color(base, s)
+	}
+
+	roles := make([]string, len(s))
+	for _, h := range hs {
+		for _, loc := range h.re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[2*h.group], loc[2*h.group+1]
+			if start < 0 {
+				continue
+			}
+
+			for i := start; i < end; i++ {
+				if roles[i] == "" {
+					roles[i] = h.role
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	for start := 0; start < len(s); {
+		end := start + 1
+		for end < len(s) && roles[end] == roles[start] {
+			end++
+		}
+
+		role := roles[start]
+		if role == "" {
+			role = base
+		}
+		sb.WriteString(t.Color(role, s[start:end]))
+
+		start = end
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSearchGrep(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "GET /health 200\n")
+	fake.AddEvent("/app/api", "s1", 2000, "Timeout calling db\n")
+	fake.AddEvent("/app/api", "s1", 3000, "GET /orders 500\n")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--grep", `timeout|5\d\d`}, want: "GET /orders 500\n"},
+		{args: []string{"--grep", `timeout|5\d\d`, "-i"}, want: "Timeout calling db\nGET /orders 500\n"},
+		{args: []string{"--grep", `^GET`, "--invert"}, want: "Timeout calling db\n"},
+	} {
+		args := append([]string{"search", "--log-group", "/app/api", "--pattern", "", "--output", "raw"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+	}
+
+	if _, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--invert"); err == nil || !strings.Contains(err.Error(), "require --grep") {
+		t.Errorf("got %v, want --grep required error", err)
+	}
+	if _, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--grep", "("); err == nil || !strings.Contains(err.Error(), "invalid --grep") {
+		t.Errorf("got %v, want invalid --grep error", err)
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	theme = newTheme(true)
+	t.Cleanup(func() {
+		theme = newTheme(false)
+	})
+
+	grep := highlight{re: regexp.MustCompile(`o+`), role: roleMatch}
+	got := theme.Highlight("foo bar\n", roleMessage, []highlight{grep})
+	want := theme.Message("f") + theme.Color(roleMatch, "oo") + theme.Message(" bar\n")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got, want := theme.Highlight("foo\n", roleMessage, nil), theme.Message("foo\n"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSearchGrepLimit(t *testing.T) {
+	// the matching events follow a page of events which are not matched
+	fake := newFakeLogs(2)
+	for i, msg := range []string{"ok 1", "ok 2", "ok 3", "failed 4", "ok 5", "failed 6", "failed 7"} {
+		fake.AddEvent("/app/api", "s1", int64(1000*(i+1)), msg+"\n")
+	}
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--output", "raw", "--grep", "failed", "--limit", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "failed 4\nfailed 6\n"; stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+}
+
+func TestHighlightJSONPretty(t *testing.T) {
+	th := newTheme(true)
+
+	grep := highlight{re: regexp.MustCompile(`time\w+`), role: roleMatch}
+	got, ok := formatJSONMessage(`{"error":"timeout"}`, JSONPretty, th, []highlight{grep})
+	if !ok {
+		t.Fatal("expected JSON message")
+	}
+
+	want := "{\n  " + th.Color(roleJSONKey, `"error"`) + ": " +
+		th.Color(roleJSONString, `"`) + th.Color(roleMatch, "timeout") + th.Color(roleJSONString, `"`) + "\n}"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// modes of formatting JSON messages
+const (
+	JSONNone    = ""
+	JSONPretty  = "pretty"
+	JSONCompact = "compact"
+)
+
+// jsonIndent is the indentation of pretty JSON messages
+const jsonIndent = "  "
+
+// formatJSONMessage reformats the message if it is a JSON object or array, coloring it with the theme.
+// The matches of the highlights are colored within each key and value.
+// Returns false if the message is not JSON, in which case it should be displayed as is.
+func formatJSONMessage(msg, mode string, th *Theme, hs []highlight) (string, bool) {
+	trimmed := strings.TrimSpace(msg)
+	if mode == JSONNone || len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid([]byte(trimmed)) {
+		return "", false
+	}
+
+	s, err := formatJSON(trimmed, mode == JSONPretty, th, hs)
+	if err != nil {
+		return "", false
+	}
+
+	// keep the trailing newline of the message
+	if strings.HasSuffix(msg, "\n") {
+		s += "\n"
+	}
+
+	return s, true
+}
+
+type jsonFrame struct {
+	object    bool
+	count     int
+	expectKey bool
+}
+
+// formatJSON re-encodes a single JSON value token by token, so keys keep their order and numbers their precision
+func formatJSON(s string, pretty bool, th *Theme, hs []highlight) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	var stack []*jsonFrame
+
+	newline := func(depth int) {
+		if pretty {
+			buf.WriteString("\n" + strings.Repeat(jsonIndent, depth))
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		// closing of an object or array
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			if top.count > 0 {
+				newline(len(stack))
+			}
+			buf.WriteRune(rune(d))
+
+			completeJSONValue(stack)
+			continue
+		}
+
+		// key of an object
+		if top != nil && top.object && top.expectKey {
+			if top.count > 0 {
+				buf.WriteString(",")
+			}
+			newline(len(stack))
+
+			key, _ := json.Marshal(tok)
+			buf.WriteString(th.Highlight(string(key), roleJSONKey, hs))
+			buf.WriteString(":")
+			if pretty {
+				buf.WriteString(" ")
+			}
+
+			top.expectKey = false
+			continue
+		}
+
+		// element of an array
+		if top != nil && !top.object {
+			if top.count > 0 {
+				buf.WriteString(",")
+			}
+			newline(len(stack))
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			buf.WriteRune(rune(v))
+			stack = append(stack, &jsonFrame{object: v == '{', expectKey: v == '{'})
+			continue
+		case string:
+			b, _ := json.Marshal(v)
+			buf.WriteString(th.Highlight(string(b), roleJSONString, hs))
+		case json.Number:
+			buf.WriteString(th.Highlight(v.String(), roleJSONNumber, hs))
+		case bool:
+			if v {
+				buf.WriteString(th.Highlight("true", roleJSONLiteral, hs))
+			} else {
+				buf.WriteString(th.Highlight("false", roleJSONLiteral, hs))
+			}
+		case nil:
+			buf.WriteString(th.Highlight("null", roleJSONLiteral, hs))
+		}
+
+		completeJSONValue(stack)
+	}
+
+	return buf.String(), nil
+}
+
+// completeJSONValue records the completion of a value within the innermost object or array
+func completeJSONValue(stack []*jsonFrame) {
+	if len(stack) == 0 {
+		return
+	}
+
+	top := stack[len(stack)-1]
+	top.count++
+	top.expectKey = top.object
+}
+
+// parseJSONObject returns the message decoded if it is a JSON object, numbers are kept as json.Number
+func parseJSONObject(msg string) (map[string]interface{}, bool) {
+	trimmed := strings.TrimSpace(msg)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil || dec.More() {
+		return nil, false
+	}
+
+	return obj, true
+}
+
+// lookupJSONPath returns the value at the dot path, e.g. req.id, where a number indexes into an array.
+// Keys containing dots are matched first, e.g. the flat keys of ECS such as {"log.level":"error"}.
+func lookupJSONPath(v interface{}, path string) (interface{}, bool) {
+	key, rest, nested := strings.Cut(path, ".")
+
+	switch it := v.(type) {
+	case map[string]interface{}:
+		if nested {
+			if v, ok := it[path]; ok {
+				return v, true
+			}
+		}
+
+		var ok bool
+		if v, ok = it[key]; !ok {
+			return nil, false
+		}
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(it) {
+			return nil, false
+		}
+		v = it[idx]
+	default:
+		return nil, false
+	}
+
+	if !nested {
+		return v, true
+	}
+
+	return lookupJSONPath(v, rest)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestFormatJSONMessage(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		mode string
+		want string
+		ok   bool
+	}{
+		{msg: `{"b":1,"a":[true,null,"x"],"c":{}}` + "\n", mode: JSONPretty, want: "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null,\n    \"x\"\n  ],\n  \"c\": {}\n}\n", ok: true},
+		{msg: "{\n  \"level\": \"info\",\n  \"n\": 1.50\n}", mode: JSONCompact, want: `{"level":"info","n":1.50}`, ok: true},
+		{msg: `[]`, mode: JSONPretty, want: `[]`, ok: true},
+		{msg: `{"a":1}`, mode: JSONNone},
+		{msg: "plain text\n", mode: JSONPretty},
+		{msg: `{"a":1} trailing`, mode: JSONPretty},
+		{msg: `{"a":`, mode: JSONPretty},
+	} {
+		got, ok := formatJSONMessage(tc.msg, tc.mode, newTheme(false), nil)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("%q %s: got %q %v, want %q %v", tc.msg, tc.mode, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestReadJSONPretty(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, `{"level":"info","msg":"hi"}`+"\n")
+	fake.AddEvent("/app/api", "s1", 2000, "not json\n")
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--json-pretty")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\n  \"level\": \"info\",\n  \"msg\": \"hi\"\n}\nnot json\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if _, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--json-pretty", "--json-compact"); err == nil {
+		t.Error("expected error for --json-pretty with --json-compact")
+	}
+}
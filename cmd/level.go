@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// log levels in order of severity
+const (
+	levelTrace = "trace"
+	levelDebug = "debug"
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+	levelFatal = "fatal"
+)
+
+var levels = []string{levelTrace, levelDebug, levelInfo, levelWarn, levelError, levelFatal}
+
+// levelAliases are the other names of the levels
+var levelAliases = map[string]string{
+	"warning":   levelWarn,
+	"err":       levelError,
+	"notice":    levelInfo,
+	"verbose":   levelDebug,
+	"critical":  levelFatal,
+	"crit":      levelFatal,
+	"alert":     levelFatal,
+	"emerg":     levelFatal,
+	"emergency": levelFatal,
+	"panic":     levelFatal,
+}
+
+// levelRank returns the severity of the level, -1 if unknown
+func levelRank(level string) int {
+	for i, it := range levels {
+		if it == level {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// normalizeLevel returns the level of the name, empty if unknown
+func normalizeLevel(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := levelAliases[name]; ok {
+		return alias
+	}
+
+	if levelRank(name) > -1 {
+		return name
+	}
+
+	return ""
+}
+
+// validateLevel returns an error if the level is unknown
+func validateLevel(level string) error {
+	if normalizeLevel(level) == "" {
+		return fmt.Errorf("invalid level %q, must be one of: %s", level, strings.Join(levels, ", "))
+	}
+
+	return nil
+}
+
+// levelKeys are the keys of the level in JSON messages
+var levelKeys = []string{"level", "severity", "lvl", "loglevel", "log.level", "levelname"}
+
+var (
+	// logfmt, e.g. level=warn
+	logfmtLevel = regexp.MustCompile(`(?i)(?:^|\s)(?:level|lvl|severity)=["']?([a-z]+)`)
+	// Lambda, e.g. 2022-10-01T00:00:00.000Z	<request id>	ERROR	message
+	lambdaLevel = regexp.MustCompile(`^\S+\t\S+\t([A-Z]+)\t`)
+	// bracketed, e.g. [ERROR]
+	bracketLevel = regexp.MustCompile(`(?i)\[(trace|debug|info|warn|warning|error|fatal|critical|panic)\]`)
+	// upper case words, e.g. WARN
+	wordLevel = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|CRITICAL|PANIC)\b`)
+)
+
+// detectLevel returns the level of the message, empty if it cannot be detected
+func detectLevel(msg string) string {
+	if obj, ok := parseJSONObject(msg); ok {
+		for _, key := range levelKeys {
+			v, ok := lookupJSONPath(obj, key)
+			if !ok {
+				continue
+			}
+
+			switch v := v.(type) {
+			case string:
+				if level := normalizeLevel(v); level != "" {
+					return level
+				}
+			case json.Number:
+				// numeric levels of pino and bunyan
+				if n, err := v.Int64(); err == nil && n >= 10 && n <= 60 {
+					return levels[n/10-1]
+				}
+			}
+		}
+
+		return ""
+	}
+
+	for _, re := range []*regexp.Regexp{logfmtLevel, lambdaLevel, bracketLevel, wordLevel} {
+		if m := re.FindStringSubmatch(msg); m != nil {
+			if level := normalizeLevel(m[1]); level != "" {
+				return level
+			}
+		}
+	}
+
+	return ""
+}
+
+// minLevelFilter returns a filter of events at or above the level, events of unknown level are kept.
+// The level of events must be detected beforehand, see printOptions.DetectLevel.
+func minLevelFilter(level string) func(LogEvent) bool {
+	min := levelRank(normalizeLevel(level))
+
+	return func(e LogEvent) bool {
+		rank := levelRank(e.Level)
+		return rank < 0 || rank >= min
+	}
+}
+
+// levelRoles are the roles of messages of each level
+var levelRoles = map[string]string{
+	levelTrace: roleLevelTrace,
+	levelDebug: roleLevelDebug,
+	levelInfo:  roleLevelInfo,
+	levelWarn:  roleLevelWarn,
+	levelError: roleLevelError,
+	levelFatal: roleLevelFatal,
+}
+
+// levelRole returns the role of messages of the level, the message role if unknown
+func levelRole(level string) string {
+	if role, ok := levelRoles[level]; ok {
+		return role
+	}
+
+	return roleMessage
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectLevel(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		want string
+	}{
+		{msg: `{"level":"WARNING","msg":"slow"}`, want: levelWarn},
+		{msg: `{"severity":"ERROR"}`, want: levelError},
+		{msg: `{"log":{"level":"debug"}}`, want: levelDebug},
+		{msg: `{"log.level":"error","log.logger":"app"}`, want: levelError},
+		{msg: `{"level":50,"msg":"pino"}`, want: levelError},
+		{msg: `{"msg":"no level, ERROR in text"}`, want: ""},
+		{msg: "[ERROR] 2022-10-01T00:00:00.000Z failed\n", want: levelError},
+		{msg: "2022-10-01 00:00:00 [warn] disk almost full", want: levelWarn},
+		{msg: "2022-10-01T00:00:00.000Z\t8f5a-12\tERROR\tInvoke Error\n", want: levelError},
+		{msg: "2022-10-01T00:00:00.000Z\t8f5a-12\tINFO\thello\n", want: levelInfo},
+		{msg: `ts=2022-10-01T00:00:00Z level=warn msg="retrying"`, want: levelWarn},
+		{msg: `time="now" lvl=crit msg=boom`, want: levelFatal},
+		{msg: "WARN connection pool exhausted", want: levelWarn},
+		{msg: "SEVERE: informational message", want: ""},
+		{msg: "START RequestId: 8f5a-12 Version: $LATEST\n", want: ""},
+	} {
+		if got := detectLevel(tc.msg); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestReadMinLevel(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, `{"level":"debug","msg":"a"}`+"\n")
+	fake.AddEvent("/app/api", "s1", 2000, "INFO b\n")
+	fake.AddEvent("/app/api", "s1", 3000, "[WARN] c\n")
+	fake.AddEvent("/app/api", "s1", 4000, "level=error msg=d\n")
+	fake.AddEvent("/app/api", "s1", 5000, "unknown e\n")
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--min-level", "warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// events of unknown level are kept
+	want := "[WARN] c\nlevel=error msg=d\nunknown e\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if _, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--min-level", "loud"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestReadMinLevelTail(t *testing.T) {
+	// the last events are below the level, and so are the events between the errors
+	fake := newFakeLogs(2)
+	for i, msg := range []string{"ERROR a", "INFO b", "ERROR c", "INFO d", "INFO e", "ERROR f", "INFO g", "INFO h", "INFO i"} {
+		fake.AddEvent("/app/api", "s1", int64(1000*(i+1)), msg+"\n")
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"--tail", "2"}, want: "ERROR c\nERROR f\n"},
+		{args: []string{"--tail", "5"}, want: "ERROR a\nERROR c\nERROR f\n"},
+		{args: []string{"--head", "2"}, want: "ERROR a\nERROR c\n"},
+	} {
+		args := append([]string{"read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--min-level", "error"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+	}
+}
+
+func TestLevelColorHighlight(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "ERROR failed to connect\n")
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--color", "always", "--grep", "failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the match stands out from the color of the level
+	if theme.Color(roleMatch, "failed") == theme.Color(roleLevelError, "failed") {
+		t.Fatal("expected the match and error colors to differ")
+	}
+
+	want := ": " + theme.Color(roleLevelError, "ERROR ") + theme.Color(roleMatch, "failed") + theme.Color(roleLevelError, " to connect\n")
+	if !strings.HasSuffix(stdout, want) {
+		t.Errorf("got %q, want suffix %q", stdout, want)
+	}
+}
+
+func TestLevelColor(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "ERROR failed\n")
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--color", "always")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ": " + theme.Color(roleLevelError, "ERROR failed\n")
+	if !strings.HasSuffix(stdout, want) {
+		t.Errorf("got %q, want suffix %q", stdout, want)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// continuationLine matches the lines of stack traces following the first, e.g. indented lines,
+	// "at com.example.Foo.bar(Foo.java:10)", "... 5 more" and "Caused by: ..."
+	continuationLine = regexp.MustCompile(`^(?:[ \t]+\S|at \S|\.\.\. \d+ more|Caused by: |Suppressed: |Traceback \(most recent call last\)|During handling of the above exception|The above exception was the direct cause)`)
+	// javaException matches a Java exception following the message it is logged with, e.g. "java.lang.IllegalStateException: boom"
+	javaException = regexp.MustCompile(`^[a-z][\w$]*(?:\.[\w$]+)+(?:Exception|Error|Throwable)\b`)
+	// pythonException matches the last line of a Python traceback, e.g. "ValueError: invalid literal"
+	pythonException = regexp.MustCompile(`^[\w.]+(?:Error|Exception|Exit|Interrupt)\b`)
+)
+
+// multilineOptions returns whether events are merged and the expression of the start of events, given by the global flags
+func multilineOptions() (bool, *regexp.Regexp, error) {
+	if !FlagMultiline && FlagMultilineStart == "" {
+		return false, nil, nil
+	}
+
+	if FlagPaged {
+		return false, nil, errors.New("--paged cannot be combined with --multiline")
+	}
+
+	if FlagMultilineStart == "" {
+		return true, nil, nil
+	}
+
+	re, err := regexp.Compile(FlagMultilineStart)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid --multiline-start: %w", err)
+	}
+
+	return true, re, nil
+}
+
+// multilineWindow is how long after its last line an event may still be continued.
+// The lines of a stack trace are logged at once, hence an event is complete once later events are past the window.
+const multilineWindow = time.Second
+
+// multilinePrinter merges continuation lines into the preceding event of the same log stream,
+// hence an event is printed once the next event of its log stream starts or it is past the window.
+// The merged events are printed in timestamp order, as the events are given.
+type multilinePrinter struct {
+	Printer
+	// start matches the first line of events, otherwise continuation lines are detected from common stack traces
+	start *regexp.Regexp
+	// pending are the events which may be continued, by log group and stream
+	pending map[string]*pendingEvent
+	// complete are the merged events in timestamp order, printed once no pending event is earlier
+	complete []LogEvent
+}
+
+type pendingEvent struct {
+	LogEvent
+	// last is the timestamp of the last line merged
+	last int64
+}
+
+func (p *multilinePrinter) Print(e LogEvent) error {
+	if p.pending == nil {
+		p.pending = make(map[string]*pendingEvent)
+	}
+
+	key := e.LogGroupName + "\x00" + e.LogStreamName
+	if pe, ok := p.pending[key]; ok && p.isContinuation(pe.Message, e) {
+		pe.Message = withNewline(pe.Message) + e.Message
+		pe.last = e.Timestamp
+	} else {
+		if ok {
+			p.completeEvent(pe.LogEvent)
+		}
+		p.pending[key] = &pendingEvent{LogEvent: e, last: e.Timestamp}
+	}
+
+	// events of the other log streams past the window
+	p.completePending(func(pe *pendingEvent) bool {
+		return pe.last+multilineWindow.Milliseconds() < e.Timestamp
+	})
+
+	return p.flush(false)
+}
+
+func (p *multilinePrinter) Close() error {
+	// the trailing output is written regardless of the limit
+	if err := p.flush(true); err != nil && !errors.Is(err, errLimitReached) {
+		return err
+	}
+
+	return p.Printer.Close()
+}
+
+// completePending completes the pending events matching the condition, in a consistent order for equal timestamps
+func (p *multilinePrinter) completePending(cond func(*pendingEvent) bool) {
+	var keys []string
+	for k, pe := range p.pending {
+		if cond(pe) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		p.completeEvent(p.pending[k].LogEvent)
+		delete(p.pending, k)
+	}
+}
+
+// completeEvent adds the event to the complete events, after those of the same timestamp
+func (p *multilinePrinter) completeEvent(e LogEvent) {
+	i := sort.Search(len(p.complete), func(i int) bool {
+		return p.complete[i].Timestamp > e.Timestamp
+	})
+
+	p.complete = append(p.complete, LogEvent{})
+	copy(p.complete[i+1:], p.complete[i:])
+	p.complete[i] = e
+}
+
+// flush prints the complete events which no pending event precedes, all events if final
+func (p *multilinePrinter) flush(final bool) error {
+	if final {
+		p.completePending(func(*pendingEvent) bool { return true })
+	}
+
+	for len(p.complete) > 0 {
+		e := p.complete[0]
+		for _, pe := range p.pending {
+			if pe.Timestamp < e.Timestamp {
+				return nil
+			}
+		}
+
+		p.complete = p.complete[1:]
+		if err := p.Printer.Print(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isContinuation reports whether the event continues the pending message of its log stream
+func (p *multilinePrinter) isContinuation(pending string, e LogEvent) bool {
+	line := strings.TrimRight(e.Message, "\r\n")
+	if line == "" {
+		return false
+	}
+
+	if p.start != nil {
+		return !p.start.MatchString(line)
+	}
+
+	if continuationLine.MatchString(line) || javaException.MatchString(line) {
+		return true
+	}
+
+	// the exception ends a Python traceback
+	return strings.Contains(pending, "Traceback (most recent call last)") && pythonException.MatchString(line)
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestReadMultiline(t *testing.T) {
+	fake := newFakeLogs(3)
+	for i, msg := range []string{
+		"ERROR request failed\n",
+		"java.lang.IllegalStateException: boom\n",
+		"\tat com.example.Foo.bar(Foo.java:10)\n",
+		"Caused by: java.io.IOException: closed\n",
+		"\t... 5 more\n",
+		"INFO next request\n",
+		"ERROR handler failed\n",
+		"Traceback (most recent call last):\n",
+		`  File "app.py", line 1, in <module>` + "\n",
+		"ValueError: invalid literal\n",
+		"INFO done\n",
+	} {
+		fake.AddEvent("/app/api", "s1", int64(1000*(i+1)), msg)
+	}
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "ndjson", "--multiline", "--grep", "Exception")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// filters apply to the merged events, which keep the first timestamp
+	want := `{"timestamp":"1970-01-01T00:00:01.000Z","ingestionTime":"1970-01-01T00:00:01.000Z","logGroupName":"/app/api","logStreamName":"s1",` +
+		`"message":"ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:10)\nCaused by: java.io.IOException: closed\n\t... 5 more\n"}` + "\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	stdout, _, err = executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--multiline", "--grep", "^INFO", "--invert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = "ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:10)\nCaused by: java.io.IOException: closed\n\t... 5 more\n" +
+		"ERROR handler failed\nTraceback (most recent call last):\n  File \"app.py\", line 1, in <module>\nValueError: invalid literal\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	// the limits count the merged events
+	for _, tc := range []struct {
+		arg  string
+		want string
+	}{
+		{arg: "--head", want: "ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:10)\nCaused by: java.io.IOException: closed\n\t... 5 more\nINFO next request\n"},
+		{arg: "--tail", want: "ERROR handler failed\nTraceback (most recent call last):\n  File \"app.py\", line 1, in <module>\nValueError: invalid literal\nINFO done\n"},
+	} {
+		stdout, _, err = executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw", "--multiline", tc.arg, "2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.arg, stdout, tc.want)
+		}
+	}
+}
+
+func TestSearchMultilineStart(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "2022-10-01 first\n")
+	fake.AddEvent("/app/api", "s2", 1500, "2022-10-01 other stream\n")
+	fake.AddEvent("/app/api", "s1", 2000, "detail of first\n")
+	fake.AddEvent("/app/api", "s1", 3000, "2022-10-01 second\n")
+	fake.AddEvent("/app/api", "s1", 4000, "detail of second\n")
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--pattern", "", "--output", "raw",
+		"--multiline-start", `^\d{4}-\d{2}-\d{2} `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lines are merged with the event of their log stream, in timestamp order of the merged events
+	want := "2022-10-01 first\ndetail of first\n2022-10-01 other stream\n2022-10-01 second\ndetail of second\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if _, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--multiline-start", "("); err == nil {
+		t.Error("expected error for invalid --multiline-start")
+	}
+}
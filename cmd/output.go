@@ -0,0 +1,558 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// supported output formats
+const (
+	OutputText   = "text"
+	OutputJSON   = "json"
+	OutputNDJSON = "ndjson"
+	OutputCSV    = "csv"
+	OutputRaw    = "raw"
+)
+
+var outputFormats = []string{OutputText, OutputJSON, OutputNDJSON, OutputCSV, OutputRaw}
+
+// validateOutput returns an error if the output format is not supported
+func validateOutput(format string) error {
+	for _, it := range outputFormats {
+		if it == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid output format %q, must be one of: %s", format, strings.Join(outputFormats, ", "))
+}
+
+// LogEvent is a log event from either GetLogEvents or FilterLogEvents
+type LogEvent struct {
+	Timestamp     int64
+	IngestionTime int64
+	LogGroupName  string
+	LogStreamName string
+	EventID       string
+	Message       string
+	// Level is the detected level of the message, set when printed with DetectLevel or as text
+	Level string
+}
+
+// fromOutputLogEvent converts an event of the given log stream from GetLogEvents
+func fromOutputLogEvent(it types.OutputLogEvent, logGroup, logStream string) LogEvent {
+	return LogEvent{
+		Timestamp:     aws.ToInt64(it.Timestamp),
+		IngestionTime: aws.ToInt64(it.IngestionTime),
+		LogGroupName:  logGroup,
+		LogStreamName: logStream,
+		Message:       aws.ToString(it.Message),
+	}
+}
+
+// fromFilteredLogEvent converts an event of the given log group from FilterLogEvents
+func fromFilteredLogEvent(it types.FilteredLogEvent, logGroup string) LogEvent {
+	return LogEvent{
+		Timestamp:     aws.ToInt64(it.Timestamp),
+		IngestionTime: aws.ToInt64(it.IngestionTime),
+		LogGroupName:  logGroup,
+		LogStreamName: aws.ToString(it.LogStreamName),
+		EventID:       aws.ToString(it.EventId),
+		Message:       aws.ToString(it.Message),
+	}
+}
+
+// formatMilli formats unix milli with millisecond precision, empty if not set
+func formatMilli(milli int64) string {
+	if milli == 0 {
+		return ""
+	}
+
+	return time.UnixMilli(milli).In(location).Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// Printer writes log events in an output format
+type Printer interface {
+	Print(LogEvent) error
+	// Close writes any trailing output, it must be called once all events are printed
+	Close() error
+}
+
+// printOptions controls what is displayed by the text printer
+type printOptions struct {
+	// ShowGroup prefixes each event with its log group
+	ShowGroup bool
+	// StreamLabel prefixes each event with a short label of its log stream
+	StreamLabel bool
+	// ShowStream prefixes each event with its full log stream name
+	ShowStream bool
+	// ShowIngestion displays the ingestion time after the timestamp
+	ShowIngestion bool
+	// ShowID displays the event ID after the timestamp
+	ShowID bool
+	// JSON is how JSON messages are formatted, also applies to the raw printer
+	JSON string
+	// Fields are the dot paths extracted from JSON messages in place of the message, applies to all printers
+	Fields []string
+	// Filters drop the events not matching any of them, applies to all printers
+	Filters []func(LogEvent) bool
+	// Highlights color their matches in messages
+	Highlights []highlight
+	// Multiline merges continuation lines into the preceding event, applies to all printers
+	Multiline bool
+	// MultilineStart matches the first line of events when merging, optional
+	MultilineStart *regexp.Regexp
+	// Limit is the number of events printed if positive, counted once they are filtered and merged
+	Limit int
+	// Skip is the number of events dropped before the events printed, counted once they are filtered and merged
+	Skip int
+	// DetectLevel sets the level of events before they are filtered, always set for the text printer
+	DetectLevel bool
+}
+
+// newPrinter returns the printer of the output format
+func newPrinter(w io.Writer, format string, opts printOptions) (Printer, error) {
+	var p Printer
+
+	switch format {
+	case OutputText:
+		p = &textPrinter{w: w, opts: opts, cols: newColumns(opts.Fields)}
+		opts.DetectLevel = true
+	case OutputJSON:
+		p = &jsonPrinter{w: w, fields: opts.Fields}
+	case OutputNDJSON:
+		p = &ndjsonPrinter{w: w, fields: opts.Fields}
+	case OutputCSV:
+		p = &csvPrinter{w: csv.NewWriter(w), fields: opts.Fields}
+	case OutputRaw:
+		p = &rawPrinter{w: w, opts: opts, cols: newColumns(opts.Fields)}
+	default:
+		return nil, validateOutput(format)
+	}
+
+	return wrapPrinter(p, opts), nil
+}
+
+// wrapPrinter returns the printer of the events which are merged, filtered and limited by the options
+func wrapPrinter(p Printer, opts printOptions) Printer {
+	if opts.Limit > 0 || opts.Skip > 0 {
+		p = &limitPrinter{Printer: p, limit: opts.Limit, skip: opts.Skip}
+	}
+
+	if len(opts.Filters) > 0 {
+		p = &filterPrinter{Printer: p, filters: opts.Filters}
+	}
+
+	// the level is detected once for the filters and the printer
+	if opts.DetectLevel {
+		p = &levelPrinter{Printer: p}
+	}
+
+	// events are merged before they are filtered, so that filters apply to whole events
+	if opts.Multiline {
+		p = &multilinePrinter{Printer: p, start: opts.MultilineStart}
+	}
+
+	return p
+}
+
+// countEvents returns the number of events printed with the options, once they are filtered and merged
+func countEvents(events []LogEvent, opts printOptions) int {
+	opts.Limit, opts.Skip = 0, 0
+
+	c := &countPrinter{}
+	p := wrapPrinter(c, opts)
+	for _, it := range events {
+		_ = p.Print(it)
+	}
+	_ = p.Close()
+
+	return c.n
+}
+
+// closePrinter closes the printer once the events are retrieved, returning err if the retrieval failed.
+// The printer is closed regardless, to terminate the output such as the closing bracket of JSON.
+// Reaching the limit of events stops the retrieval without failing it.
+func closePrinter(p Printer, err error) error {
+	if errors.Is(err, errLimitReached) {
+		err = nil
+	}
+
+	if cerr := p.Close(); err == nil && !errors.Is(cerr, errLimitReached) {
+		err = cerr
+	}
+
+	return err
+}
+
+// errLimitReached is returned once the limit of events is printed, to stop retrieving events
+var errLimitReached = errors.New("limit reached")
+
+// limitPrinter drops the first skip events and prints up to limit events if positive
+type limitPrinter struct {
+	Printer
+	limit int
+	skip  int
+	n     int
+}
+
+func (p *limitPrinter) Print(e LogEvent) error {
+	if p.skip > 0 {
+		p.skip--
+		return nil
+	}
+
+	if p.limit > 0 && p.n >= p.limit {
+		return errLimitReached
+	}
+
+	if err := p.Printer.Print(e); err != nil {
+		return err
+	}
+	p.n++
+
+	// stop as soon as the limit is reached, rather than on the next event
+	if p.limit > 0 && p.n >= p.limit {
+		return errLimitReached
+	}
+
+	return nil
+}
+
+// levelPrinter sets the level of events
+type levelPrinter struct {
+	Printer
+}
+
+func (p *levelPrinter) Print(e LogEvent) error {
+	e.Level = detectLevel(e.Message)
+	return p.Printer.Print(e)
+}
+
+// countPrinter counts the events instead of printing them
+type countPrinter struct {
+	n int
+}
+
+func (p *countPrinter) Print(LogEvent) error {
+	p.n++
+	return nil
+}
+
+func (p *countPrinter) Close() error {
+	return nil
+}
+
+// filterPrinter prints the events matching all of its filters
+type filterPrinter struct {
+	Printer
+	filters []func(LogEvent) bool
+}
+
+func (p *filterPrinter) Print(e LogEvent) error {
+	for _, it := range p.filters {
+		if !it(e) {
+			return nil
+		}
+	}
+
+	return p.Printer.Print(e)
+}
+
+// textPrinter prints colorized timestamp and message, the extracted fields are preceded by their header
+type textPrinter struct {
+	w      io.Writer
+	opts   printOptions
+	cols   *columns
+	header bool
+}
+
+func (p *textPrinter) Print(e LogEvent) error {
+	var sb strings.Builder
+
+	if p.opts.ShowGroup {
+		sb.WriteString(theme.Group("["+e.LogGroupName+"]") + " ")
+	}
+
+	switch {
+	case p.opts.ShowStream:
+		sb.WriteString(theme.Stream(e.LogStreamName, e.LogStreamName) + " ")
+	case p.opts.StreamLabel && e.LogStreamName != "":
+		sb.WriteString(theme.Stream(e.LogStreamName, shortStreamName(e.LogStreamName)) + " ")
+	}
+
+	sb.WriteString(theme.Timestamp(time.UnixMilli(e.Timestamp).In(location).Format(time.RFC3339)))
+
+	if p.opts.ShowIngestion {
+		sb.WriteString(" " + theme.Label("ingested=") + time.UnixMilli(e.IngestionTime).In(location).Format(time.RFC3339))
+	}
+
+	if p.opts.ShowID && e.EventID != "" {
+		sb.WriteString(" " + theme.Label("id=") + e.EventID)
+	}
+
+	sb.WriteString(": ")
+
+	var msg string
+	if values, ok := extractFields(e.Message, p.opts.Fields); ok {
+		row, widened := p.cols.format(values)
+
+		// the field names above the columns of the first row, and of the rows after they are widened
+		if widened || !p.header {
+			p.header = true
+
+			indent := strings.Repeat(" ", visibleLen(sb.String()))
+			if _, err := io.WriteString(p.w, indent+theme.Label(p.cols.header())+"\n"); err != nil {
+				return err
+			}
+		}
+
+		msg = theme.Highlight(row, levelRole(e.Level), p.opts.Highlights)
+	} else {
+		msg = p.message(e.Message, e.Level)
+	}
+
+	_, err := io.WriteString(p.w, sb.String()+msg)
+	return err
+}
+
+// ansiSequence matches the escape sequences of colors
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// visibleLen returns the number of characters displayed of s, which may be colored
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(s, ""))
+}
+
+// streamLabelLength is the maximum length of a short log stream label
+const streamLabelLength = 8
+
+// shortStreamName returns the distinguishing end of the log stream name, e.g. the instance ID of
+// 2022/10/01/[$LATEST]0123456789abcdef
+func shortStreamName(name string) string {
+	if idx := strings.LastIndexAny(name, "/]"); idx > -1 && idx < len(name)-1 {
+		name = name[idx+1:]
+	}
+
+	if len(name) > streamLabelLength {
+		name = name[len(name)-streamLabelLength:]
+	}
+
+	return name
+}
+
+// message returns the formatted JSON of the message, otherwise the message as is colored by its level,
+// ending with a newline
+func (p *textPrinter) message(msg, level string) string {
+	s, ok := formatJSONMessage(msg, p.opts.JSON, theme, p.opts.Highlights)
+	if !ok {
+		s = theme.Highlight(msg, levelRole(level), p.opts.Highlights)
+	}
+
+	// the newline of the message is within its color, if any
+	if !strings.HasSuffix(msg, "\n") {
+		s += "\n"
+	}
+
+	return s
+}
+
+func (p *textPrinter) Close() error {
+	return nil
+}
+
+// rawPrinter prints the message only, the extracted fields are preceded by their header
+type rawPrinter struct {
+	w      io.Writer
+	opts   printOptions
+	cols   *columns
+	header bool
+}
+
+func (p *rawPrinter) Print(e LogEvent) error {
+	msg := e.Message
+	if values, ok := extractFields(msg, p.opts.Fields); ok {
+		row, widened := p.cols.format(values)
+
+		// the field names above the columns of the first row, and of the rows after they are widened
+		if widened || !p.header {
+			p.header = true
+			row = p.cols.header() + "\n" + row
+		}
+
+		msg = row
+	} else if s, ok := formatJSONMessage(msg, p.opts.JSON, theme, nil); ok {
+		msg = s
+	}
+
+	_, err := io.WriteString(p.w, withNewline(msg))
+	return err
+}
+
+func (p *rawPrinter) Close() error {
+	return nil
+}
+
+type jsonLogEvent struct {
+	Timestamp     string `json:"timestamp"`
+	IngestionTime string `json:"ingestionTime,omitempty"`
+	LogGroupName  string `json:"logGroupName,omitempty"`
+	LogStreamName string `json:"logStreamName,omitempty"`
+	EventID       string `json:"eventId,omitempty"`
+	// Message is omitted in place of the extracted fields, messages are never empty otherwise
+	Message string      `json:"message,omitempty"`
+	Fields  *jsonFields `json:"fields,omitempty"`
+}
+
+// toJSONLogEvent converts the event, with the fields extracted from its message if it is a JSON object
+func toJSONLogEvent(e LogEvent, fields []string) jsonLogEvent {
+	it := jsonLogEvent{
+		Timestamp:     formatMilli(e.Timestamp),
+		IngestionTime: formatMilli(e.IngestionTime),
+		LogGroupName:  e.LogGroupName,
+		LogStreamName: e.LogStreamName,
+		EventID:       e.EventID,
+	}
+
+	if values, ok := extractFields(e.Message, fields); ok {
+		it.Fields = &jsonFields{names: fields, values: values}
+	} else {
+		it.Message = e.Message
+	}
+
+	return it
+}
+
+// jsonPrinter prints a JSON array of events, one event per line
+type jsonPrinter struct {
+	w      io.Writer
+	fields []string
+	n      int
+}
+
+func (p *jsonPrinter) Print(e LogEvent) error {
+	b, err := json.Marshal(toJSONLogEvent(e, p.fields))
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if p.n == 0 {
+		sep = "[\n  "
+	}
+	p.n++
+
+	_, err = fmt.Fprintf(p.w, "%s%s", sep, b)
+	return err
+}
+
+func (p *jsonPrinter) Close() error {
+	if p.n == 0 {
+		_, err := io.WriteString(p.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(p.w, "\n]\n")
+	return err
+}
+
+// ndjsonPrinter prints a JSON object per line
+type ndjsonPrinter struct {
+	w      io.Writer
+	fields []string
+}
+
+func (p *ndjsonPrinter) Print(e LogEvent) error {
+	return json.NewEncoder(p.w).Encode(toJSONLogEvent(e, p.fields))
+}
+
+func (p *ndjsonPrinter) Close() error {
+	return nil
+}
+
+var csvHeader = []string{"timestamp", "ingestion_time", "log_group_name", "log_stream_name", "event_id", "message"}
+
+// csvPrinter prints a header followed by a record per event,
+// the fields are appended as columns with the message left empty when they are extracted
+type csvPrinter struct {
+	w      *csv.Writer
+	fields []string
+	header bool
+}
+
+func (p *csvPrinter) Print(e LogEvent) error {
+	if err := p.writeHeader(); err != nil {
+		return err
+	}
+
+	record := []string{
+		formatMilli(e.Timestamp),
+		formatMilli(e.IngestionTime),
+		e.LogGroupName,
+		e.LogStreamName,
+		e.EventID,
+		strings.TrimRight(e.Message, "\r\n"),
+	}
+
+	if len(p.fields) > 0 {
+		values, ok := extractFields(e.Message, p.fields)
+		if ok {
+			record[len(record)-1] = ""
+		}
+
+		for i := range p.fields {
+			if ok {
+				record = append(record, fieldString(values[i]))
+			} else {
+				record = append(record, "")
+			}
+		}
+	}
+
+	if err := p.w.Write(record); err != nil {
+		return err
+	}
+
+	// flush every record so output is not held back
+	p.w.Flush()
+	return p.w.Error()
+}
+
+func (p *csvPrinter) Close() error {
+	if err := p.writeHeader(); err != nil {
+		return err
+	}
+
+	p.w.Flush()
+	return p.w.Error()
+}
+
+// writeHeader writes the header once
+func (p *csvPrinter) writeHeader() error {
+	if p.header {
+		return nil
+	}
+	p.header = true
+
+	header := append(append([]string{}, csvHeader...), p.fields...)
+	return p.w.Write(header)
+}
+
+// withNewline ensures the string ends with a newline
+func withNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+
+	return s + "\n"
+}
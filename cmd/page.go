@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/manifoldco/promptui"
+)
+
+// actions of the page prompt
+const (
+	pageNext     = "Next"
+	pagePrevious = "Previous"
+	pageEnd      = "Jump to end"
+	pageQuit     = "Quit"
+)
+
+// validatePaged returns an error if the paged flags cannot be used
+func validatePaged() error {
+	if !FlagPaged {
+		return nil
+	}
+
+	if !isInteractive() {
+		return errors.New("--paged requires stdin to be a terminal")
+	}
+
+	if FlagPageSize < 1 || FlagPageSize > maxLimit {
+		return fmt.Errorf("--page-size must be between 1 and %d", maxLimit)
+	}
+
+	return nil
+}
+
+// pageLogs displays the events of the log stream a page at a time, prompting to move forward or backward.
+// Reaching either end of the log stream is reported to w, apart from the events.
+func pageLogs(ctx context.Context, client LogsAPI, w io.Writer, logGroup, logStream string, size int32, fn func([]types.OutputLogEvent) error) error {
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		StartFromHead: aws.Bool(true),
+		Limit:         aws.Int32(size),
+	}
+
+	for {
+		out, err := client.GetLogEvents(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(out.Events) == 0 {
+			fmt.Fprintln(w, theme.Label("No more events"))
+		}
+
+		if err := fn(out.Events); err != nil {
+			return err
+		}
+
+		action, err := promptPage("Navigate", pageNext, pagePrevious, pageEnd, pageQuit)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case pageNext:
+			// a forward token must be used with StartFromHead, including after jumping to the end
+			input.NextToken = out.NextForwardToken
+			input.StartFromHead = aws.Bool(true)
+		case pagePrevious:
+			input.NextToken = out.NextBackwardToken
+		case pageEnd:
+			input.NextToken = nil
+			input.StartFromHead = aws.Bool(false)
+		case pageQuit:
+			return nil
+		}
+	}
+}
+
+// pageFilteredLogs displays the events of the log group matching the pattern a page at a time, prompting to move forward
+func pageFilteredLogs(ctx context.Context, client LogsAPI, logGroup, pattern string, start, end *int64, size int32, fn func([]types.FilteredLogEvent) error) error {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		FilterPattern: aws.String(pattern),
+		StartTime:     start,
+		EndTime:       end,
+		Limit:         aws.Int32(size),
+	}
+
+	var page int
+	for {
+		out, err := client.FilterLogEvents(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		page++
+		if err := fn(out.Events); err != nil {
+			return err
+		}
+
+		if out.NextToken == nil {
+			return nil
+		}
+
+		action, err := promptPage(fmt.Sprintf("Page %d", page), pageNext, pageQuit)
+		if err != nil {
+			return err
+		}
+
+		if action == pageQuit {
+			return nil
+		}
+
+		input.NextToken = out.NextToken
+	}
+}
+
+// promptPage prompts for the next action of the paged mode, it is replaced in tests
+var promptPage = func(label string, actions ...string) (string, error) {
+	tmpl := &promptui.SelectTemplates{
+		Label:    label,
+		Active:   "{{ icon }} {{ . | underline | selected }}",
+		Inactive: "  {{ . }}",
+		FuncMap:  theme.PromptFuncs(),
+	}
+
+	prompt := promptui.Select{
+		Size:         len(actions),
+		Items:        actions,
+		Templates:    tmpl,
+		HideSelected: true,
+	}
+
+	_, result, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed %v", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chzyer/readline"
+)
+
+var FlagNoPager bool
+
+// defaultPager is used when $PAGER is not set
+const defaultPager = "less -R"
+
+// pagerDelay is how long output is held back while deciding whether it fits within the screen
+const pagerDelay = 500 * time.Millisecond
+
+var errPagerQuit = errors.New("pager quit")
+
+// pager writes output directly while it fits within the screen, and through $PAGER once it exceeds the screen.
+// Output is streamed to the pager as it is written. Output is held back for the delay at most, such as while
+// waiting for more events, it is then written directly until the output exceeds the screen.
+type pager struct {
+	mu      sync.Mutex
+	out     io.Writer
+	enabled bool
+	height  int
+	width   int
+	delay   time.Duration
+
+	buf     bytes.Buffer
+	timer   *time.Timer
+	flushed bool
+	rows    int
+	col     int
+	esc     int
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	quit  bool
+}
+
+// newPager returns a pager of the output, which only pages when enabled, not disabled by flag and
+// the output is a terminal
+func newPager(out io.Writer, enabled bool) *pager {
+	p := &pager{out: out}
+
+	f, ok := out.(*os.File)
+	if !enabled || FlagNoPager || !ok || !readline.IsTerminal(int(f.Fd())) {
+		return p
+	}
+
+	width, height, err := readline.GetSize(int(f.Fd()))
+	if err != nil || height <= 0 {
+		return p
+	}
+
+	p.enabled = true
+	p.height = height
+	p.width = width
+	p.delay = pagerDelay
+
+	return p
+}
+
+func (p *pager) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.enabled {
+		return p.out.Write(b)
+	}
+
+	if p.quit {
+		return 0, errPagerQuit
+	}
+
+	n := len(b)
+	if p.stdin == nil {
+		p.count(b)
+
+		// keep the last line for the shell prompt
+		if p.rows < p.height-1 {
+			if p.flushed {
+				return p.out.Write(b)
+			}
+
+			p.buf.Write(b)
+			if p.timer == nil && p.delay > 0 {
+				p.timer = time.AfterFunc(p.delay, p.flush)
+			}
+
+			return n, nil
+		}
+		p.stopTimer()
+
+		// the output held back, if any, is followed by the output exceeding the screen
+		p.buf.Write(b)
+
+		// output exceeds the screen, fall back to writing directly if the pager cannot be started
+		if err := p.start(); err != nil {
+			p.enabled = false
+
+			_, err := p.out.Write(p.buf.Bytes())
+			p.buf.Reset()
+
+			return n, err
+		}
+
+		b = p.buf.Bytes()
+		defer p.buf.Reset()
+	}
+
+	if _, err := p.stdin.Write(b); err != nil {
+		p.quit = true
+		return 0, errPagerQuit
+	}
+
+	return n, nil
+}
+
+// count adds the screen rows taken by the output, including the lines wrapped at the screen width.
+// Escape sequences such as colors take no space.
+func (p *pager) count(b []byte) {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+
+		switch {
+		case p.esc == 1:
+			// control sequences begin with ESC [, other escape sequences are a single character
+			p.esc = 0
+			if r == '[' {
+				p.esc = 2
+			}
+			continue
+		case p.esc == 2:
+			// control sequences end with a character in the range @ to ~
+			if r >= '@' && r <= '~' {
+				p.esc = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\x1b':
+			p.esc = 1
+		case '\n':
+			p.rows++
+			p.col = 0
+		case '\r':
+			p.col = 0
+		case '\t':
+			p.advance(8 - p.col%8)
+		default:
+			p.advance(1)
+		}
+	}
+}
+
+// advance moves the cursor by n columns, wrapping to the next row past the screen width
+func (p *pager) advance(n int) {
+	p.col += n
+	if p.width > 0 && p.col > p.width {
+		p.rows++
+		p.col = n
+	}
+}
+
+// flush writes the output held back directly once the delay has passed, if it still fits within the screen.
+// The output is then written directly until it exceeds the screen.
+func (p *pager) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.enabled || p.stdin != nil {
+		return
+	}
+
+	p.flushed = true
+
+	// a failed write is reported by the next one
+	_, _ = p.out.Write(p.buf.Bytes())
+	p.buf.Reset()
+}
+
+func (p *pager) stopTimer() {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+}
+
+// start runs the pager given by $PAGER
+func (p *pager) start() error {
+	args := strings.Fields(envOr("PAGER", defaultPager))
+	if len(args) == 0 || args[0] == "cat" {
+		return errors.New("no pager")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = p.out
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// ctrl-c is for the pager to handle while it runs
+	signal.Ignore(os.Interrupt)
+
+	p.cmd = cmd
+	p.stdin = stdin
+
+	return nil
+}
+
+// Close writes any buffered output and waits for the pager to exit, returning err if any.
+// err is discarded if the pager quit before all output is written, as the user chose to stop reading.
+func (p *pager) Close(err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopTimer()
+
+	if p.cmd == nil {
+		// output fits within the screen
+		if _, werr := p.out.Write(p.buf.Bytes()); err == nil {
+			err = werr
+		}
+		p.buf.Reset()
+
+		return err
+	}
+
+	p.stdin.Close()
+	_ = p.cmd.Wait()
+
+	signal.Reset(os.Interrupt)
+
+	if p.quit {
+		return nil
+	}
+
+	return err
+}
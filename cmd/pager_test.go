@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPager(t *testing.T) {
+	t.Setenv("PAGER", "sed s/^/>/")
+
+	for _, tc := range []struct {
+		lines int
+		want  string
+	}{
+		{lines: 2, want: "1\n2\n"},
+		{lines: 5, want: ">1\n>2\n>3\n>4\n>5\n"},
+	} {
+		var buf bytes.Buffer
+		p := &pager{out: &buf, enabled: true, height: 4}
+
+		for i := 1; i <= tc.lines; i++ {
+			if _, err := fmt.Fprintf(p, "%d\n", i); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := p.Close(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%d lines: got %q, want %q", tc.lines, got, tc.want)
+		}
+	}
+}
+
+func TestPagerWrappedLines(t *testing.T) {
+	t.Setenv("PAGER", "sed s/^/>/")
+
+	// two lines wrapped at the width take four rows, the colors take no space
+	var buf bytes.Buffer
+	p := &pager{out: &buf, enabled: true, height: 4, width: 5}
+
+	if _, err := fmt.Fprint(p, "1234567\n\x1b[31m12345\x1b[0m6\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Close(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), ">1234567\n>\x1b[31m12345\x1b[0m6\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPagerDelay(t *testing.T) {
+	t.Setenv("PAGER", "sed s/^/>/")
+
+	var buf bytes.Buffer
+	p := &pager{out: &buf, enabled: true, height: 4, delay: time.Millisecond}
+
+	if _, err := fmt.Fprintln(p, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// output which fits within the screen is not held back until more is written
+	deadline := time.Now().Add(time.Second)
+	for {
+		p.mu.Lock()
+		got := buf.String()
+		p.mu.Unlock()
+
+		if got == "1\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %q after the delay", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// written directly until the output exceeds the screen, then through the pager
+	for i := 2; i <= 5; i++ {
+		if _, err := fmt.Fprintln(p, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.Close(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "1\n2\n>3\n>4\n>5\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// patternHighlights returns the highlights of the terms of the filter pattern, none if it has none to highlight
+func patternHighlights(pattern string) []highlight {
+	terms, selected := patternTerms(pattern)
+
+	var hs []highlight
+	if len(terms) > 0 {
+		// longer terms first, so that they are preferred over the terms they contain
+		sort.SliceStable(terms, func(i, j int) bool {
+			return len(terms[i]) > len(terms[j])
+		})
+
+		if re, err := regexp.Compile(strings.Join(terms, "|")); err == nil {
+			hs = append(hs, highlight{re: re, role: rolePattern})
+		}
+	}
+
+	// values selected by their key or position are the first group of their expression
+	for _, it := range selected {
+		if re, err := regexp.Compile(it); err == nil {
+			hs = append(hs, highlight{re: re, role: rolePattern, group: 1})
+		}
+	}
+
+	return hs
+}
+
+var (
+	// jsonSelector matches a JSON property compared for equality, e.g. $.level = "error"
+	jsonSelector = regexp.MustCompile(`\$([\w.\[\]*-]*)\s*==?\s*("(?:[^"\\]|\\.)*"|[^\s,&|)}\]]+)`)
+	// spaceSelector matches a field compared for equality within a space-delimited selector, e.g. status=4*
+	spaceSelector = regexp.MustCompile(`^\s*([\w-]+)\s*==?\s*("(?:[^"\\]|\\.)*"|[^\s,&|)}\]]+)`)
+	// selectorOperator separates the conditions of a field within a space-delimited selector
+	selectorOperator = regexp.MustCompile(`\|\||&&`)
+	// arrayIndex matches the array indexes of a JSON property, e.g. [0]
+	arrayIndex = regexp.MustCompile(`\[\d*\*?\]`)
+)
+
+// spaceField is the expression of a field of space-delimited messages, which may be quoted or bracketed
+const spaceField = `(?:"[^"]*"|\[[^\]]*\]|\S+)`
+
+// patternTerms returns the regular expressions of the terms which match in the messages of the filter pattern.
+// Excluded terms (-term) are skipped since they never appear in the matched messages.
+// Numeric values of selectors, which are common in messages, are returned apart as selected values: expressions
+// which also match their key or position, with the value as the first group.
+func patternTerms(pattern string) ([]string, []string) {
+	pattern = strings.TrimSpace(pattern)
+
+	switch {
+	case strings.HasPrefix(pattern, "{"):
+		var terms, selected []string
+		for _, m := range jsonSelector.FindAllStringSubmatch(pattern, -1) {
+			value, numeric := selectorTerm(m[2])
+			if value == "" {
+				continue
+			}
+
+			if !numeric {
+				terms = append(terms, value)
+				continue
+			}
+
+			// the value of the last property of the path, unless it is within an array
+			path := strings.Split(m[1], ".")
+			key := path[len(path)-1]
+			if key == "" || arrayIndex.MatchString(key) {
+				continue
+			}
+
+			selected = append(selected, `"`+regexp.QuoteMeta(key)+`"\s*:\s*(`+value+`)(?:[\s,}\]]|$)`)
+		}
+
+		return terms, selected
+	case strings.HasPrefix(pattern, "["):
+		var terms, selected []string
+
+		fields := strings.Split(strings.TrimSuffix(strings.TrimPrefix(pattern, "["), "]"), ",")
+		for i, field := range fields {
+			// a field may be compared with several values, e.g. status=404 || status=410
+			for _, cond := range selectorOperator.Split(field, -1) {
+				m := spaceSelector.FindStringSubmatch(cond)
+				if m == nil {
+					continue
+				}
+
+				value, numeric := selectorTerm(m[2])
+				switch {
+				case value == "":
+				case numeric:
+					// the value at the position of the field
+					selected = append(selected, `^\s*(?:`+spaceField+`\s+){`+strconv.Itoa(i)+`}(`+value+`)(?:\s|$)`)
+				default:
+					terms = append(terms, value)
+				}
+			}
+		}
+
+		return terms, selected
+	}
+
+	var terms []string
+	for i := 0; i < len(pattern); {
+		if pattern[i] == ' ' || pattern[i] == '\t' {
+			i++
+			continue
+		}
+
+		// optional (?term) and excluded (-term) terms
+		exclude := pattern[i] == '-'
+		if pattern[i] == '-' || pattern[i] == '?' {
+			i++
+		}
+
+		var term string
+		switch {
+		case i < len(pattern) && pattern[i] == '"':
+			var n int
+			term, n = quotedTerm(pattern[i:])
+			term = regexp.QuoteMeta(term)
+			i += n
+		case i < len(pattern) && pattern[i] == '%':
+			// regular expressions are enclosed in %
+			end := strings.IndexByte(pattern[i+1:], '%')
+			if end < 0 {
+				end = len(pattern) - i - 1
+			}
+			term = pattern[i+1 : i+1+end]
+			if _, err := regexp.Compile(term); err != nil {
+				term = ""
+			}
+			i += end + 2
+		default:
+			end := strings.IndexAny(pattern[i:], " \t")
+			if end < 0 {
+				end = len(pattern) - i
+			}
+			term = regexp.QuoteMeta(pattern[i : i+end])
+			i += end
+		}
+
+		if !exclude && term != "" {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms, nil
+}
+
+// quotedTerm returns the term enclosed in double quotes at the start of s, where \" is a quote within the term,
+// and the length of the quoted term in s
+func quotedTerm(s string) (string, int) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			i++
+			sb.WriteByte(s[i])
+		case s[i] == '"':
+			return sb.String(), i + 1
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	// unterminated
+	return sb.String(), len(s)
+}
+
+// selectorTerm returns the regular expression of a selector value, and whether it is a number
+func selectorTerm(value string) (string, bool) {
+	if s, err := strconv.Unquote(value); err == nil {
+		return wildcardRegexp(s), false
+	}
+
+	digits := strings.TrimPrefix(value, "-")
+	numeric := digits != "" && digits[0] >= '0' && digits[0] <= '9'
+
+	return wildcardRegexp(value), numeric
+}
+
+// wildcardRegexp returns the regular expression of a selector value, where * matches any characters within a word
+func wildcardRegexp(s string) string {
+	parts := strings.Split(s, "*")
+	for i, it := range parts {
+		parts[i] = regexp.QuoteMeta(it)
+	}
+
+	return strings.Join(parts, `[^\s"]*`)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatternTerms(t *testing.T) {
+	for _, tc := range []struct {
+		pattern  string
+		want     []string
+		selected []string
+	}{
+		{pattern: "", want: nil},
+		{pattern: `ERROR`, want: []string{`ERROR`}},
+		{pattern: `ERROR "connection reset" -DEBUG`, want: []string{`ERROR`, `connection reset`}},
+		{pattern: `?ERROR ?WARN`, want: []string{`ERROR`, `WARN`}},
+		{pattern: `-"health check" 1.2.3.4`, want: []string{`1\.2\.3\.4`}},
+		{pattern: `%5\d\d%`, want: []string{`5\d\d`}},
+		{pattern: `{ $.level = "error" && $.status != 200 && $.latency >= 100 }`, want: []string{`error`}},
+		{pattern: `"say \"hi\"" -"a \\ b"`, want: []string{`say "hi"`}},
+		{
+			pattern:  `{ ($.user.id == 42) || ($.path = "/api/*") || $.tags[0] = 7 }`,
+			want:     []string{`/api/[^\s"]*`},
+			selected: []string{`"id"\s*:\s*(42)(?:[\s,}\]]|$)`},
+		},
+		{
+			pattern:  `[ip, user, status=4* || status=500, method="GET"]`,
+			want:     []string{`GET`},
+			selected: []string{`^\s*(?:` + spaceField + `\s+){2}(4[^\s"]*)(?:\s|$)`, `^\s*(?:` + spaceField + `\s+){2}(500)(?:\s|$)`},
+		},
+	} {
+		got, selected := patternTerms(tc.pattern)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.pattern, got, tc.want)
+		}
+		if !reflect.DeepEqual(selected, tc.selected) {
+			t.Errorf("%s: got selected %q, want %q", tc.pattern, selected, tc.selected)
+		}
+	}
+}
+
+func TestPatternHighlightsSelected(t *testing.T) {
+	theme = newTheme(true)
+	t.Cleanup(func() {
+		theme = newTheme(false)
+	})
+
+	for _, tc := range []struct {
+		pattern string
+		msg     string
+		want    string
+	}{
+		// only the value of the key is highlighted, not the other occurrences of the number
+		{
+			pattern: `{ $.user.id = 42 }`,
+			msg:     `{"retries":42,"user":{"id":42}}`,
+			want:    theme.Message(`{"retries":42,"user":{"id":`) + theme.Color(rolePattern, "42") + theme.Message("}}"),
+		},
+		{
+			pattern: `[ip, size, status=404]`,
+			msg:     `10.0.0.1 404 404`,
+			want:    theme.Message("10.0.0.1 404 ") + theme.Color(rolePattern, "404"),
+		},
+	} {
+		if got := theme.Highlight(tc.msg, roleMessage, patternHighlights(tc.pattern)); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+}
+
+func TestSearchPatternHighlight(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "WARN upstream connection reset\n")
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--pattern", `"connection reset"`, "--color", "always")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the terms stand out from the color of the level
+	want := theme.Color(roleLevelWarn, "WARN upstream ") + theme.Color(rolePattern, "connection reset") + theme.Color(roleLevelWarn, "\n")
+	if !strings.HasSuffix(stdout, want) {
+		t.Errorf("got %q, want suffix %q", stdout, want)
+	}
+}
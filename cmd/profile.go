@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/manifoldco/promptui"
+)
+
+// regions is the curated list of regions for the region prompt
+var regions = []string{
+	"us-east-1",
+	"us-east-2",
+	"us-west-1",
+	"us-west-2",
+	"ca-central-1",
+	"sa-east-1",
+	"eu-west-1",
+	"eu-west-2",
+	"eu-west-3",
+	"eu-central-1",
+	"eu-north-1",
+	"eu-south-1",
+	"me-south-1",
+	"af-south-1",
+	"ap-east-1",
+	"ap-south-1",
+	"ap-northeast-1",
+	"ap-northeast-2",
+	"ap-northeast-3",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ap-southeast-3",
+}
+
+// resolveProfileRegion prompts for the profile and region unless given by flag
+func resolveProfileRegion(ctx context.Context) error {
+	if !isInteractive() {
+		return nil
+	}
+
+	if FlagProfile == "" {
+		profiles, err := getProfiles()
+		if err != nil {
+			return err
+		}
+
+		if len(profiles) > 0 {
+			FlagProfile, err = promptProfile(ctx, profiles)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if FlagRegion == "" {
+		// default to the region of the profile
+		var current string
+		if sc, err := config.LoadSharedConfigProfile(ctx, profileOrDefault()); err == nil {
+			current = sc.Region
+		}
+
+		region, err := promptRegion(current)
+		if err != nil {
+			return err
+		}
+		FlagRegion = region
+	}
+
+	return nil
+}
+
+// profileOrDefault returns the profile given by flag or environment, otherwise the default profile
+func profileOrDefault() string {
+	if FlagProfile != "" {
+		return FlagProfile
+	}
+
+	return envOr("AWS_PROFILE", "default")
+}
+
+// getProfiles returns the profile names in the shared config and credentials files
+func getProfiles() ([]string, error) {
+	files := []struct {
+		name   string
+		prefix string
+	}{
+		{name: envOr("AWS_CONFIG_FILE", config.DefaultSharedConfigFilename()), prefix: "profile "},
+		{name: envOr("AWS_SHARED_CREDENTIALS_FILE", config.DefaultSharedCredentialsFilename())},
+	}
+
+	exists := make(map[string]bool)
+	for _, f := range files {
+		names, err := readProfiles(f.name, f.prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, it := range names {
+			exists[it] = true
+		}
+	}
+
+	var profiles []string
+	for k := range exists {
+		profiles = append(profiles, k)
+	}
+
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// readProfiles returns the profile names of the sections in the file, a missing file has no profiles.
+// Sections other than default are expected to begin with the prefix, if any.
+func readProfiles(name, prefix string) ([]string, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var profiles []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+
+		section := strings.TrimSpace(line[1 : len(line)-1])
+		switch {
+		case section == "default":
+			profiles = append(profiles, section)
+		case prefix == "":
+			profiles = append(profiles, section)
+		case strings.HasPrefix(section, prefix):
+			profiles = append(profiles, strings.TrimSpace(strings.TrimPrefix(section, prefix)))
+		}
+	}
+
+	return profiles, scanner.Err()
+}
+
+func promptProfile(ctx context.Context, profiles []string) (string, error) {
+	tmpl := &promptui.SelectTemplates{
+		Label:    "Select Profile",
+		Active:   "{{ icon }} {{ . | underline | selected }}",
+		Inactive: "  {{ . }}",
+		Selected: `{{ "Profile:" | label }}	{{ .Name }}{{ if .Account }} {{ .Account | label }}{{ end }}`,
+		FuncMap:  theme.PromptFuncs(),
+	}
+
+	searcher := func(input string, index int) bool {
+		item := profiles[index]
+
+		label := strings.ToLower(item)
+		search := strings.ToLower(input)
+
+		return strings.Contains(label, search)
+	}
+
+	// start at the current profile
+	var pos int
+	for i, it := range profiles {
+		if it == profileOrDefault() {
+			pos = i
+		}
+	}
+
+	prompt := promptui.Select{
+		Size:         10,
+		Items:        profiles,
+		Templates:    tmpl,
+		Searcher:     searcher,
+		CursorPos:    pos,
+		HideSelected: true,
+	}
+
+	_, result, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed %v", err)
+	}
+
+	// the selected template is rendered once the account identity is known
+	selected := struct {
+		Name    string
+		Account string
+	}{
+		Name:    result,
+		Account: getAccountIdentity(ctx, result),
+	}
+
+	t, err := template.New("").Funcs(theme.PromptFuncs()).Parse(tmpl.Selected)
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.Execute(os.Stdout, selected); err != nil {
+		return "", err
+	}
+	fmt.Println()
+
+	return result, nil
+}
+
+// getAccountIdentity returns the account and caller ARN of the profile, or the failure reason.
+// The identity is of the role given by flag, if any, as it is the one the logs are accessed with.
+func getAccountIdentity(ctx context.Context, profile string) string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithSharedConfigProfile(profile),
+		// region is required by sts, regardless of the region the profile is used with
+		config.WithDefaultRegion("us-east-1"),
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return "(unknown account)"
+	}
+
+	out, err := newSTSClient(assumeRole(cfg)).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "(unknown account)"
+	}
+
+	return fmt.Sprintf("(%s %s)", *out.Account, *out.Arn)
+}
+
+func promptRegion(current string) (string, error) {
+	tmpl := &promptui.SelectTemplates{
+		Label:    "Select Region",
+		Active:   "{{ icon }} {{ . | underline | selected }}",
+		Inactive: "  {{ . }}",
+		Selected: `{{ "Region:" | label }}	{{ . }}`,
+		FuncMap:  theme.PromptFuncs(),
+	}
+
+	items := regions
+
+	// include the current region if not in the curated list
+	var pos int
+	for i, it := range items {
+		if it == current {
+			pos = i
+		}
+	}
+	if current != "" && items[pos] != current {
+		items = append([]string{current}, items...)
+	}
+
+	searcher := func(input string, index int) bool {
+		item := items[index]
+
+		label := strings.ToLower(item)
+		search := strings.ToLower(input)
+
+		return strings.Contains(label, search)
+	}
+
+	prompt := promptui.Select{
+		Size:      10,
+		Items:     items,
+		Templates: tmpl,
+		Searcher:  searcher,
+		CursorPos: pos,
+	}
+
+	_, result, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("prompt failed %v", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetProfiles(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// sections other than profiles are skipped in the config file
+	t.Setenv("AWS_CONFIG_FILE", write("config", `[default]
+region = us-east-1
+
+[profile dev]
+sso_session = corp
+
+[ profile  staging ]
+# comment
+[sso-session corp]
+sso_region = us-east-1
+
+[services local]
+`))
+
+	// sections are profiles in the credentials file, which may repeat those of the config file
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", write("credentials", `[default]
+aws_access_key_id = a
+
+[prod]
+aws_access_key_id = b
+[dev]
+`))
+
+	profiles, err := getProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(profiles, ","), "default,dev,prod,staging"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// missing files have no profiles
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "missing"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "missing"))
+
+	profiles, err = getProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(profiles) != 0 {
+		t.Errorf("got %q, want no profiles", profiles)
+	}
+}
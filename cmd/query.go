@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+)
+
+// queryCmd represents the query command
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Run a CloudWatch Logs Insights query and display the results",
+	RunE:  executeQuery,
+}
+
+var (
+	FlagQuery     string
+	FlagQueryFile string
+)
+
+const defaultQuery = "fields @timestamp, @message | sort @timestamp desc | limit 20"
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVarP(&FlagQuery, "query", "q", "", "insights query string, skips the query prompt")
+	queryCmd.Flags().StringVarP(&FlagQueryFile, "query-file", "f", "", "file containing the insights query, skips the query prompt")
+	queryCmd.Flags().StringSliceVar(&FlagLogGroups, "log-group", nil, "log group names or glob patterns to query (repeatable), skips the log group prompt")
+	queryCmd.Flags().StringVar(&FlagStart, "start", "", "start time, e.g. 15m, 2h ago, yesterday 09:00 or RFC3339, defaults to an hour before end")
+	queryCmd.Flags().StringVar(&FlagEnd, "end", "", "end time, e.g. now, 1h ago or RFC3339, defaults to now")
+}
+
+func executeQuery(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	// results are rows of fields rather than log events, hence there is no message to print on its own
+	if FlagOutput == OutputRaw {
+		return errors.New("--output raw is not supported by query")
+	}
+
+	// init cwl client
+	client, err := newLogsAPI(ctx)
+	if err != nil {
+		return err
+	}
+
+	// log groups
+	selLogGroups, err := resolveLogGroups(ctx, client, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+
+	// query string
+	query, err := resolveQuery()
+	if err != nil {
+		return err
+	}
+
+	// start date
+	start, err := resolveDateTime(cmd, "start", FlagStart, "Start")
+	if err != nil {
+		return err
+	}
+
+	// end date
+	end, err := resolveDateTime(cmd, "end", FlagEnd, "End")
+	if err != nil {
+		return err
+	}
+
+	// insights requires a time range
+	if end == nil {
+		end = aws.Int64(time.Now().UnixMilli())
+	}
+	if start == nil {
+		start = aws.Int64(*end - time.Hour.Milliseconds())
+	}
+
+	// stop the query on ctrl-c
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// query
+	rows, err := runQuery(ctx, client, selLogGroups, query, *start, *end)
+	if err != nil {
+		return err
+	}
+
+	// display
+	out := newPager(cmd.OutOrStdout(), true)
+
+	return out.Close(printRows(out, FlagOutput, rows))
+}
+
+// resolveQuery returns the query given by flag or file, otherwise prompts for it
+func resolveQuery() (string, error) {
+	if FlagQuery != "" && FlagQueryFile != "" {
+		return "", errors.New("--query and --query-file are mutually exclusive")
+	}
+
+	if FlagQuery != "" {
+		return FlagQuery, nil
+	}
+
+	if FlagQueryFile != "" {
+		b, err := os.ReadFile(FlagQueryFile)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	if !isInteractive() {
+		return "", errMissingFlag("query")
+	}
+
+	return promptQuery()
+}
+
+func promptQuery() (string, error) {
+	prompt := promptui.Prompt{
+		Label:     "Query",
+		Default:   defaultQuery,
+		AllowEdit: true,
+		Templates: promptTemplates(),
+	}
+
+	return prompt.Run()
+}
+
+// runQuery starts an insights query and waits for its completion.
+// The query is stopped if the context is cancelled before it completes.
+func runQuery(ctx context.Context, client LogsAPI, logGroups []string, query string, start, end int64) ([][]types.ResultField, error) {
+	out, err := client.StartQuery(ctx, &cloudwatchlogs.StartQueryInput{
+		LogGroupNames: logGroups,
+		QueryString:   aws.String(query),
+		StartTime:     aws.Int64(start / 1000),
+		EndTime:       aws.Int64(end / 1000),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queryID := out.QueryId
+
+	spin := newSpinner(os.Stderr)
+	defer spin.Clear()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	var lastPoll time.Time
+	status := types.QueryStatusScheduled
+	for {
+		select {
+		case <-ctx.Done():
+			// the context is already cancelled, hence a fresh one to stop the query
+			_, _ = client.StopQuery(context.Background(), &cloudwatchlogs.StopQueryInput{
+				QueryId: queryID,
+			})
+			return nil, errors.New("query cancelled")
+		case <-ticker.C:
+		}
+
+		spin.Tick(string(status))
+
+		if time.Since(lastPoll) < time.Second {
+			continue
+		}
+		lastPoll = time.Now()
+
+		res, err := client.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{
+			QueryId: queryID,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				// handled by the cancellation above
+				continue
+			}
+			return nil, err
+		}
+
+		status = res.Status
+		switch status {
+		case types.QueryStatusComplete:
+			return res.Results, nil
+		case types.QueryStatusFailed, types.QueryStatusCancelled, types.QueryStatusTimeout:
+			return nil, fmt.Errorf("query %s", strings.ToLower(string(status)))
+		}
+	}
+}
+
+// printRows renders the query results in the output format
+func printRows(w io.Writer, format string, rows [][]types.ResultField) error {
+	columns := resultColumns(rows)
+
+	switch format {
+	case OutputJSON, OutputNDJSON:
+		for i, row := range rows {
+			b, err := json.Marshal(jsonFields{names: columns, values: resultValues(columns, row)})
+			if err != nil {
+				return err
+			}
+
+			// the same layout as the json and ndjson printers of log events
+			var layout string
+			switch {
+			case format == OutputNDJSON:
+				layout = "%s\n"
+			case i == 0:
+				layout = "[\n  %s"
+			default:
+				layout = ",\n  %s"
+			}
+
+			if _, err := fmt.Fprintf(w, layout, b); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case format == OutputNDJSON:
+			return nil
+		case len(rows) == 0:
+			_, err := io.WriteString(w, "[]\n")
+			return err
+		}
+
+		_, err := io.WriteString(w, "\n]\n")
+		return err
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var record []string
+			for _, it := range resultValues(columns, row) {
+				record = append(record, fieldString(it))
+			}
+
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return printTable(w, columns, rows)
+}
+
+// resultColumns returns the fields of the query results in order of first appearance
+func resultColumns(rows [][]types.ResultField) []string {
+	var columns []string
+	exists := make(map[string]bool)
+	for _, row := range rows {
+		for _, it := range row {
+			field := aws.ToString(it.Field)
+			if field == "@ptr" || exists[field] {
+				continue
+			}
+
+			exists[field] = true
+			columns = append(columns, field)
+		}
+	}
+
+	return columns
+}
+
+// resultValues returns the values of the row by column, nil for the missing ones.
+// Timestamps are converted into the configured time zone.
+func resultValues(columns []string, row []types.ResultField) []interface{} {
+	values := make(map[string]string, len(row))
+	for _, it := range row {
+		field := aws.ToString(it.Field)
+		value := aws.ToString(it.Value)
+
+		if field == "@timestamp" || field == "@ingestionTime" {
+			value = toLocation(value)
+		}
+
+		values[field] = value
+	}
+
+	cells := make([]interface{}, len(columns))
+	for i, c := range columns {
+		if v, ok := values[c]; ok {
+			cells[i] = v
+		}
+	}
+
+	return cells
+}
+
+// printTable renders the query results as an aligned table
+func printTable(w io.Writer, columns []string, rows [][]types.ResultField) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "No results")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, row := range rows {
+		var cells []string
+		for _, it := range resultValues(columns, row) {
+			// keep each row in a single line
+			cells = append(cells, strings.ReplaceAll(strings.TrimSpace(fieldString(it)), "\n", " "))
+		}
+
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// spinner displays progress on a terminal, it does nothing otherwise
+type spinner struct {
+	w       io.Writer
+	enabled bool
+	frame   int
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+func newSpinner(f *os.File) *spinner {
+	return &spinner{
+		w:       f,
+		enabled: readline.IsTerminal(int(f.Fd())),
+	}
+}
+
+// Tick advances the spinner with the given status
+func (s *spinner) Tick(status string) {
+	if !s.enabled {
+		return
+	}
+
+	s.frame = (s.frame + 1) % len(spinnerFrames)
+	fmt.Fprintf(s.w, "\r\033[K%s %s", spinnerFrames[s.frame], status)
+}
+
+// Clear removes the spinner from the line
+func (s *spinner) Clear() {
+	if !s.enabled {
+		return
+	}
+
+	fmt.Fprint(s.w, "\r\033[K")
+}
+
+// insightsTimeLayout is the layout of timestamps in insights results, which are in UTC
+const insightsTimeLayout = "2006-01-02 15:04:05.000"
+
+// toLocation converts an insights timestamp into the configured time zone, returned as is if it cannot be parsed
+func toLocation(value string) string {
+	t, err := time.ParseInLocation(insightsTimeLayout, value, time.UTC)
+	if err != nil {
+		return value
+	}
+
+	return t.In(location).Format(insightsTimeLayout)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1664582400000, "hello world")
+	fake.AddEvent("/app/web", "s1", 1664582401000, "multi\nline")
+	fake.AddEvent("/app/api", "s1", 1664582500000, "outside range")
+
+	stdout, _, err := executeCommand(t, fake, "query",
+		"--log-group", "/app/api",
+		"--log-group", "/app/web",
+		"--query", "fields @timestamp, @message",
+		"--start", "2022-10-01T00:00:00Z",
+		"--end", "2022-10-01T00:01:00Z",
+		"--tz", "UTC",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"@timestamp               @message",
+		"2022-10-01 00:00:00.000  hello world",
+		"2022-10-01 00:00:01.000  multi line",
+		"",
+	}, "\n")
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+}
+
+func TestQueryMissingQuery(t *testing.T) {
+	fake := newFakeLogs(10)
+
+	_, _, err := executeCommand(t, fake, "query", "--log-group", "/app/api")
+	if err == nil || !strings.Contains(err.Error(), "--query is required") {
+		t.Errorf("got %v, want missing --query error", err)
+	}
+}
+
+func TestQueryOutput(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1664582400000, "hello, world")
+	fake.AddEvent("/app/api", "s1", 1664582401000, "bye")
+
+	for _, tc := range []struct {
+		output string
+		want   string
+	}{
+		{
+			output: "ndjson",
+			want: `{"@timestamp":"2022-10-01 00:00:00.000","@message":"hello, world"}` + "\n" +
+				`{"@timestamp":"2022-10-01 00:00:01.000","@message":"bye"}` + "\n",
+		},
+		{
+			output: "json",
+			want: "[\n" +
+				`  {"@timestamp":"2022-10-01 00:00:00.000","@message":"hello, world"},` + "\n" +
+				`  {"@timestamp":"2022-10-01 00:00:01.000","@message":"bye"}` + "\n]\n",
+		},
+		{
+			output: "csv",
+			want:   "@timestamp,@message\n2022-10-01 00:00:00.000,\"hello, world\"\n2022-10-01 00:00:01.000,bye\n",
+		},
+	} {
+		stdout, _, err := executeCommand(t, fake, "query",
+			"--log-group", "/app/api",
+			"--query", "fields @timestamp, @message",
+			"--start", "2022-10-01T00:00:00Z",
+			"--end", "2022-10-01T00:01:00Z",
+			"--tz", "UTC",
+			"--output", tc.output,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.output, stdout, tc.want)
+		}
+	}
+
+	if _, _, err := executeCommand(t, fake, "query", "--log-group", "/app/api", "--query", "fields @message", "--output", "raw"); err == nil {
+		t.Error("expected error for --output raw")
+	}
+}
+
+func TestRunQueryCancel(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.running = true
+
+	// cancelled while polling the running query
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	_, err := runQuery(ctx, fake, []string{"/app/api"}, "fields @message", 0, 1000)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("got %v, want query cancelled", err)
+	}
+
+	if fake.calls["GetQueryResults"] == 0 {
+		t.Error("got no polls before the cancellation")
+	}
+
+	if len(fake.stopped) != 1 || fake.stopped[0] != "query-0" {
+		t.Errorf("got stopped %q, want the query stopped", fake.stopped)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -14,6 +15,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	FlagHead int
+	FlagTail int
+)
+
 // readCmd represents the read command
 var readCmd = &cobra.Command{
 	Use:   "read",
@@ -23,68 +29,169 @@ var readCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(readCmd)
-}
 
-var iconSelect = promptui.Styler(promptui.FGCyan)(promptui.IconSelect)
+	readCmd.Flags().StringVar(&FlagLogGroup, "log-group", "", "log group name, skips the log group prompt")
+	readCmd.Flags().StringVar(&FlagStream, "stream", "", "log stream name, skips the log stream prompt")
+	readCmd.Flags().StringVar(&FlagStreamPrefix, "stream-prefix", "", "only list log streams starting with the prefix")
+	readCmd.Flags().BoolVar(&FlagPaged, "paged", false, "display a page of events at a time, prompting to move forward or backward")
+	readCmd.Flags().IntVar(&FlagPageSize, "page-size", 50, "number of events per page when paged")
+	readCmd.Flags().IntVar(&FlagHead, "head", 0, "only display the first N events")
+	readCmd.Flags().IntVar(&FlagTail, "tail", 0, "only display the last N events")
+	addFilterFlags(readCmd)
+	addDisplayFlags(readCmd, "merge continuation lines such as stack traces into the preceding event")
+}
 
 func executeRead(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
-	// init cwl client
-	client, err := newClient(ctx)
+	if err := validatePaged(); err != nil {
+		return err
+	}
+
+	if err := validateFields(FlagFields); err != nil {
+		return err
+	}
+
+	filters, highlights, err := eventFilters()
 	if err != nil {
 		return err
 	}
 
-	// get cloudwatch log groups
-	logGroups, err := getLogGroups(ctx, client)
+	multiline, multilineStart, err := multilineOptions()
 	if err != nil {
 		return err
 	}
 
-	// prompt: log group
-	var selLogGroup string
-	if FlagGroup {
-		selLogGroup, err = promptLogGroupWithGrouping(logGroups)
-	} else {
-		selLogGroup, err = promptLogGroup(logGroups)
+	switch {
+	case FlagHead < 0 || FlagTail < 0:
+		return errors.New("--head and --tail must not be negative")
+	case FlagHead > 0 && FlagTail > 0:
+		return errors.New("--head and --tail are mutually exclusive")
+	case FlagPaged && (FlagHead > 0 || FlagTail > 0):
+		return errors.New("--paged cannot be combined with --head or --tail")
 	}
+
+	// init cwl client
+	client, err := newLogsAPI(ctx)
 	if err != nil {
 		return err
 	}
 
-	// get log streams by log group
-	logStreams, err := getLogStreams(ctx, client, selLogGroup)
+	// log group
+	selLogGroup, err := resolveLogGroup(ctx, client)
 	if err != nil {
 		return err
 	}
 
-	// prompt: log stream
-	selStream, err := promptLogStream(logStreams)
+	// log stream
+	selStream, err := resolveLogStream(ctx, client, selLogGroup)
 	if err != nil {
 		return err
 	}
 
-	// query
-	logs, err := getLogs(ctx, client, selLogGroup, selStream)
+	// prompts of the paged mode cannot be displayed through a pager
+	out := newPager(cmd.OutOrStdout(), !FlagPaged)
+
+	opts := printOptions{
+		JSON:           jsonMode(),
+		Fields:         FlagFields,
+		Filters:        filters,
+		Highlights:     highlights,
+		Multiline:      multiline,
+		MultilineStart: multilineStart,
+		Limit:          FlagHead,
+		DetectLevel:    FlagMinLevel != "",
+	}
+
+	// the last events are retrieved before display, as the number of them to skip depends on the filters
+	var logs []types.OutputLogEvent
+	if FlagTail > 0 {
+		logs, opts.Skip, err = getTailLogs(ctx, client, selLogGroup, selStream, FlagTail, opts)
+		if err != nil {
+			return out.Close(err)
+		}
+		opts.Limit = FlagTail
+	}
+
+	p, err := newPrinter(out, FlagOutput, opts)
 	if err != nil {
 		return err
 	}
 
-	// display
-	for _, it := range logs {
-		print(*it.Message, *it.Timestamp)
+	display := func(logs []types.OutputLogEvent) error {
+		for _, it := range logs {
+			if err := p.Print(fromOutputLogEvent(it, selLogGroup, selStream)); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	}
 
-	return nil
+	// query and display each page as it arrives, or as requested when paged
+	switch {
+	case FlagPaged:
+		err = pageLogs(ctx, client, cmd.ErrOrStderr(), selLogGroup, selStream, int32(FlagPageSize), display)
+	case FlagTail > 0:
+		err = display(logs)
+	default:
+		err = getLogs(ctx, client, selLogGroup, selStream, FlagHead, display)
+	}
+
+	return out.Close(closePrinter(p, err))
+}
+
+// resolveLogGroup returns the log group given by flag, otherwise prompts for it
+func resolveLogGroup(ctx context.Context, client LogsAPI) (string, error) {
+	if FlagLogGroup != "" {
+		return FlagLogGroup, nil
+	}
+
+	if !isInteractive() {
+		return "", errMissingFlag("log-group")
+	}
+
+	// get cloudwatch log groups
+	logGroups, err := getCachedLogGroups(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	// prompt: log group
+	if FlagGroup {
+		return promptLogGroupWithGrouping(logGroups)
+	}
+
+	return promptLogGroup(logGroups)
+}
+
+// resolveLogStream returns the log stream given by flag, otherwise prompts for it
+func resolveLogStream(ctx context.Context, client LogsAPI, logGroup string) (string, error) {
+	if FlagStream != "" {
+		return FlagStream, nil
+	}
+
+	if !isInteractive() {
+		return "", errMissingFlag("stream")
+	}
+
+	// get log streams by log group
+	logStreams, err := getLogStreams(ctx, client, logGroup, FlagStreamPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	// prompt: log stream
+	return promptLogStream(logStreams)
 }
 
 func promptLogGroup(logGroups []string) (string, error) {
 	tmpl := &promptui.SelectTemplates{
 		Label:    "Select Log Group",
-		Active:   fmt.Sprintf("%s {{ . | underline | cyan }}", iconSelect),
+		Active:   "{{ icon }} {{ . | underline | selected }}",
 		Inactive: "  {{ . }}",
-		Selected: `{{ "Log Group:" | faint }}	{{ . }}`,
+		Selected: `{{ "Log Group:" | label }}	{{ . }}`,
+		FuncMap:  theme.PromptFuncs(),
 	}
 
 	searcher := func(input string, index int) bool {
@@ -117,8 +224,9 @@ func promptLogGroupWithGrouping(logGroups []string) (string, error) {
 	// prompt: 1/2
 	tmpl1 := &promptui.SelectTemplates{
 		Label:    "Select Log Group - 1/2",
-		Active:   fmt.Sprintf(`%s {{ if eq . ""}}{{ "others" | underline | cyan }}{{ else }}{{ . | underline | cyan }}{{ end }}`, iconSelect),
+		Active:   `{{ icon }} {{ if eq . ""}}{{ "others" | underline | selected }}{{ else }}{{ . | underline | selected }}{{ end }}`,
 		Inactive: `  {{ if eq . ""}}others{{ else }}{{ . }}{{ end }}`,
+		FuncMap:  theme.PromptFuncs(),
 	}
 
 	prompt1 := promptui.Select{
@@ -138,9 +246,10 @@ func promptLogGroupWithGrouping(logGroups []string) (string, error) {
 
 	tmpl2 := &promptui.SelectTemplates{
 		Label:    "Select Log Group - 2/2",
-		Active:   fmt.Sprintf("%s {{ . | underline | cyan }}", iconSelect),
+		Active:   "{{ icon }} {{ . | underline | selected }}",
 		Inactive: "  {{ . }}",
-		Selected: `{{ "Log Group:" | faint }}	` + service + `{{ . }}`,
+		Selected: `{{ "Log Group:" | label }}	` + service + `{{ . }}`,
+		FuncMap:  theme.PromptFuncs(),
 	}
 
 	searcher := func(input string, index int) bool {
@@ -171,10 +280,11 @@ func promptLogGroupWithGrouping(logGroups []string) (string, error) {
 
 func promptLogStream(items []LogStream) (string, error) {
 	tmpl := &promptui.SelectTemplates{
-		Label:    "Select Log Stream",
-		Active:   fmt.Sprintf(`%s {{ .Name | underline | cyan }}{{ .Date.Format " - 15:04:05" | underline | cyan }}`, iconSelect),
-		Inactive: `  {{ .Name }}{{ .Date.Format " - 15:04:05" }}`,
-		Selected: `{{ "Log Stream:" | faint }}	{{ .Name }}`,
+		Label:    "Select Log Stream (" + zoneName() + ")",
+		Active:   `{{ icon }} {{ .Name | underline | selected }}{{ if not .Date.IsZero }}{{ .Date.Format " - 15:04:05" | underline | selected }}{{ end }}`,
+		Inactive: `  {{ .Name }}{{ if not .Date.IsZero }}{{ .Date.Format " - 15:04:05" }}{{ end }}`,
+		Selected: `{{ "Log Stream:" | label }}	{{ .Name }}`,
+		FuncMap:  theme.PromptFuncs(),
 	}
 
 	searcher := func(input string, index int) bool {
@@ -258,7 +368,7 @@ func toResourceMap(logGroups []string) ResourceMap {
 }
 
 // getLogGroups retrieves all CloudWatch Logs
-func getLogGroups(ctx context.Context, client *cloudwatchlogs.Client) ([]string, error) {
+func getLogGroups(ctx context.Context, client LogsAPI) ([]string, error) {
 	var lg []string
 
 	var nextToken *string
@@ -290,31 +400,72 @@ type LogStream struct {
 	Date time.Time
 }
 
-func getLogStreams(ctx context.Context, client *cloudwatchlogs.Client, logGroup string) ([]LogStream, error) {
-	out, err := client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+// getLogStreams retrieves all log streams of the log group, most recent event first.
+// Streams without any event are placed last.
+func getLogStreams(ctx context.Context, client LogsAPI, logGroup, prefix string) ([]LogStream, error) {
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName: aws.String(logGroup),
 		Descending:   aws.Bool(true),
-	})
-	if err != nil {
-		return nil, err
+		OrderBy:      types.OrderByLastEventTime,
+	}
+
+	// ordering by last event time cannot be combined with a prefix, hence sorted after retrieval instead
+	if prefix != "" {
+		input.LogStreamNamePrefix = aws.String(prefix)
+		input.OrderBy = types.OrderByLogStreamName
 	}
 
 	var ls []LogStream
-	for _, it := range out.LogStreams {
-		ls = append(ls, LogStream{
-			Name: *it.LogStreamName,
-			Date: time.UnixMilli(*it.LastEventTimestamp).Local(),
-		})
+	for {
+		out, err := client.DescribeLogStreams(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, it := range out.LogStreams {
+			var date time.Time
+			if it.LastEventTimestamp != nil {
+				date = time.UnixMilli(*it.LastEventTimestamp).In(location)
+			}
+
+			ls = append(ls, LogStream{
+				Name: *it.LogStreamName,
+				Date: date,
+			})
+		}
+
+		input.NextToken = out.NextToken
+		if input.NextToken == nil {
+			break
+		}
 	}
 
+	sort.SliceStable(ls, func(i, j int) bool {
+		return ls[i].Date.After(ls[j].Date)
+	})
+
 	return ls, nil
 }
 
-func getLogs(ctx context.Context, client *cloudwatchlogs.Client, logGroup, logStream string) ([]types.OutputLogEvent, error) {
-	// TODO: consider handling of pagination from CLI instead (e.g prompt for "more")
+// maxLimit is the maximum number of events returned by a single call
+const maxLimit = 10000
 
-	var logs []types.OutputLogEvent
+// pageLimit returns the limit of the next call given the remaining number of events, nil if unlimited
+func pageLimit(remaining int) *int32 {
+	if remaining <= 0 {
+		return nil
+	}
+
+	if remaining > maxLimit {
+		remaining = maxLimit
+	}
+
+	return aws.Int32(int32(remaining))
+}
 
+// getLogs retrieves the events of the log stream from the head, calling fn for every page until it returns an error.
+// Pages are of up to limit events if positive, as no more are displayed.
+func getLogs(ctx context.Context, client LogsAPI, logGroup, logStream string, limit int, fn func([]types.OutputLogEvent) error) error {
 	var next *string
 	for {
 		out, err := client.GetLogEvents(ctx, &cloudwatchlogs.GetLogEventsInput{
@@ -322,18 +473,87 @@ func getLogs(ctx context.Context, client *cloudwatchlogs.Client, logGroup, logSt
 			LogStreamName: &logStream,
 			StartFromHead: aws.Bool(true),
 			NextToken:     next,
+			Limit:         pageLimit(limit),
 		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if next != nil && *next == *out.NextForwardToken {
 			break
 		}
 
-		logs = append(logs, out.Events...)
+		if err := fn(out.Events); err != nil {
+			return err
+		}
 		next = out.NextForwardToken
 	}
 
+	return nil
+}
+
+// getTailLogs retrieves enough of the last events of the log stream to display the last n events with the options,
+// returning the number of displayed events to skip before them
+func getTailLogs(ctx context.Context, client LogsAPI, logGroup, logStream string, n int, opts printOptions) ([]types.OutputLogEvent, int, error) {
+	var count int
+	logs, err := getLastLogs(ctx, client, logGroup, logStream, n, func(logs []types.OutputLogEvent) bool {
+		events := make([]LogEvent, len(logs))
+		for i, it := range logs {
+			events[i] = fromOutputLogEvent(it, logGroup, logStream)
+		}
+
+		count = countEvents(events, opts)
+
+		// the earliest event may be missing lines merged from the events before it, hence it is not displayed
+		if opts.Multiline {
+			return count > n
+		}
+
+		return count >= n
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if count < n {
+		return logs, 0, nil
+	}
+
+	return logs, count - n, nil
+}
+
+// getLastLogs retrieves the events of the log stream by reading backwards from the end, until enough reports that
+// enough events are retrieved or the head of the log stream is reached. Pages are of up to n events.
+// The events are returned in chronological order.
+func getLastLogs(ctx context.Context, client LogsAPI, logGroup, logStream string, n int, enough func([]types.OutputLogEvent) bool) ([]types.OutputLogEvent, error) {
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(logStream),
+		StartFromHead: aws.Bool(false),
+		Limit:         pageLimit(n),
+	}
+
+	var logs []types.OutputLogEvent
+	for {
+
+		out, err := client.GetLogEvents(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		// earlier events are prepended, a page may be empty before the head of the stream is reached
+		logs = append(append([]types.OutputLogEvent{}, out.Events...), logs...)
+
+		if len(out.Events) > 0 && enough(logs) {
+			break
+		}
+
+		// the same backward token is returned once the head of the stream is reached
+		if input.NextToken != nil && *input.NextToken == *out.NextBackwardToken {
+			break
+		}
+		input.NextToken = out.NextBackwardToken
+	}
+
 	return logs, nil
 }
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestRead(t *testing.T) {
+	fake := newFakeLogs(2)
+	for i, msg := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		fake.AddEvent("/app/api", "stream-1", int64(1000*(i+1)), msg)
+	}
+	fake.AddEvent("/app/api", "stream-2", 500, "other\n")
+
+	stdout, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api", "--stream", "stream-1", "--output", "raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "one\ntwo\nthree\nfour\nfive\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+}
+
+func TestReadMissingStream(t *testing.T) {
+	fake := newFakeLogs(2)
+	fake.AddEvent("/app/api", "stream-1", 1000, "one\n")
+
+	_, _, err := executeCommand(t, fake, "read", "--log-group", "/app/api")
+	if err == nil || !strings.Contains(err.Error(), "--stream is required") {
+		t.Errorf("got %v, want missing --stream error", err)
+	}
+}
+
+func TestGetLogStreams(t *testing.T) {
+	fake := newFakeLogs(1)
+	fake.AddEvent("/app/api", "b", 3000, "b\n")
+	fake.AddEvent("/app/api", "a", 1000, "a\n")
+	fake.AddEvent("/app/api", "c", 2000, "c\n")
+
+	for _, tc := range []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "", want: []string{"b", "c", "a"}},
+		{prefix: "c", want: []string{"c"}},
+	} {
+		streams, err := getLogStreams(context.Background(), fake, "/app/api", tc.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, it := range streams {
+			got = append(got, it.Name)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("prefix %q: got %v, want %v", tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestToResourceMap(t *testing.T) {
+	rm := toResourceMap([]string{"/aws/lambda/orders", "/aws/lambda/payments", "/ecs/web", "standalone"})
+
+	if got := strings.Join(rm["/aws/lambda/"], ","); got != "orders,payments" {
+		t.Errorf("got %q, want lambda resources", got)
+	}
+
+	if got := strings.Join(rm.Services(), ","); got != "/aws/lambda/,/ecs/," {
+		t.Errorf("got services %q", got)
+	}
+}
+
+func TestReadHeadTail(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		want  string
+		calls int
+	}{
+		{args: []string{"--head", "3"}, want: "1\n2\n3\n", calls: 2},
+		{args: []string{"--tail", "3"}, want: "5\n6\n7\n", calls: 2},
+		{args: []string{"--tail", "10"}, want: "1\n2\n3\n4\n5\n6\n7\n", calls: 5},
+	} {
+		fake := newFakeLogs(2)
+		for i := 1; i <= 7; i++ {
+			fake.AddEvent("/app/api", "s1", int64(i*1000), fmt.Sprintf("%d\n", i))
+		}
+
+		args := append([]string{"read", "--log-group", "/app/api", "--stream", "s1", "--output", "raw"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+
+		// pagination stops as soon as the limit is reached
+		if got := fake.calls["GetLogEvents"]; got != tc.calls {
+			t.Errorf("%v: got %d calls, want %d", tc.args, got, tc.calls)
+		}
+	}
+}
+
+// emptyPageLogs returns an empty page from the end of the log stream before the events,
+// as CloudWatch Logs may do for a sparse log stream
+type emptyPageLogs struct {
+	*fakeLogs
+	end    int
+	served bool
+}
+
+func (e *emptyPageLogs) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	if e.served || params.NextToken != nil {
+		return e.fakeLogs.GetLogEvents(ctx, params, optFns...)
+	}
+	e.served = true
+
+	return &cloudwatchlogs.GetLogEventsOutput{
+		NextForwardToken:  aws.String(fmt.Sprintf("f/%d", e.end)),
+		NextBackwardToken: aws.String(fmt.Sprintf("b/%d", e.end)),
+	}, nil
+}
+
+func TestGetLastLogsEmptyPage(t *testing.T) {
+	fake := newFakeLogs(2)
+	for i := 1; i <= 3; i++ {
+		fake.AddEvent("/app/api", "s1", int64(i*1000), fmt.Sprintf("%d", i))
+	}
+
+	enough := func(logs []types.OutputLogEvent) bool {
+		return len(logs) >= 2
+	}
+
+	logs, err := getLastLogs(context.Background(), &emptyPageLogs{fakeLogs: fake, end: 3}, "/app/api", "s1", 2, enough)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, it := range logs {
+		got = append(got, aws.ToString(it.Message))
+	}
+
+	if strings.Join(got, ",") != "2,3" {
+		t.Errorf("got %q, want the events before the empty page", got)
+	}
+}
+
+func TestReadPaged(t *testing.T) {
+	fake := newFakeLogs(10)
+	for i, msg := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		fake.AddEvent("/app/api", "stream-1", int64(1000*(i+1)), msg)
+	}
+
+	orig := promptPage
+	t.Cleanup(func() {
+		promptPage = orig
+	})
+
+	// forward past the end, back a page, then jump to the end and forward past it
+	actions := []string{pageNext, pageNext, pageNext, pagePrevious, pageEnd, pageNext, pageQuit}
+	promptPage = func(label string, _ ...string) (string, error) {
+		if len(actions) == 0 {
+			return "", fmt.Errorf("unexpected prompt %q", label)
+		}
+
+		action := actions[0]
+		actions = actions[1:]
+
+		return action, nil
+	}
+
+	stdout, stderr, err := executeInteractive(t, fake, "read", "--log-group", "/app/api", "--stream", "stream-1", "--output", "raw", "--paged", "--page-size", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "one\ntwo\nthree\nfour\nfive\nfour\nfive\nfour\nfive\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if !strings.Contains(stderr, "No more events") {
+		t.Errorf("got stderr %q, want end of events", stderr)
+	}
+}
+
+func TestReadTextNewline(t *testing.T) {
+	// messages of most loggers do not end with a newline
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "plain")
+	fake.AddEvent("/app/api", "s1", 2000, `{"a":1}`)
+	fake.AddEvent("/app/api", "s1", 3000, "with newline\n")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{
+			args: nil,
+			want: "1970-01-01T00:00:01Z: plain\n1970-01-01T00:00:02Z: {\"a\":1}\n1970-01-01T00:00:03Z: with newline\n",
+		},
+		{
+			args: []string{"--json-pretty"},
+			want: "1970-01-01T00:00:01Z: plain\n1970-01-01T00:00:02Z: {\n  \"a\": 1\n}\n1970-01-01T00:00:03Z: with newline\n",
+		},
+	} {
+		args := append([]string{"read", "--log-group", "/app/api", "--stream", "s1", "--tz", "UTC"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+	}
+}
@@ -17,22 +17,100 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
-	. "github.com/logrusorgru/aurora"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/chzyer/readline"
 	"github.com/spf13/cobra"
 )
 
-var FlagGroup bool
+var (
+	FlagGroup  bool
+	FlagPick   bool
+	FlagOutput string
+	FlagTZ     string
+)
+
+// flags for the AWS client
+var (
+	FlagProfile     string
+	FlagRegion      string
+	FlagRoleARN     string
+	FlagEndpointURL string
+)
+
+// location is the time zone of entered and displayed times, set from FlagTZ
+var location = time.Local
+
+// flags shared by the commands, when set the matching prompt is skipped
+var (
+	FlagLogGroup     string
+	FlagLogGroups    []string
+	FlagStream       string
+	FlagStreamPrefix string
+	FlagPattern      string
+	FlagStart        string
+	FlagEnd          string
+)
+
+// flags shared by the commands which control what is displayed
+var (
+	FlagPaged          bool
+	FlagPageSize       int
+	FlagShowStream     bool
+	FlagShowIngestion  bool
+	FlagShowID         bool
+	FlagJSONPretty     bool
+	FlagJSONCompact    bool
+	FlagFields         []string
+	FlagMultiline      bool
+	FlagMultilineStart string
+)
+
+// flags shared by the commands which filter the events after they are retrieved
+var (
+	FlagWhere      string
+	FlagGrep       string
+	FlagIgnoreCase bool
+	FlagInvert     bool
+	FlagMinLevel   string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "cwlr",
 	Short: "CLI tool for interacting with AWS CloudWatch Logs",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		loc, err := time.LoadLocation(FlagTZ)
+		if err != nil {
+			return fmt.Errorf("invalid time zone %q: %w", FlagTZ, err)
+		}
+		location = loc
+
+		if err := validateOutput(FlagOutput); err != nil {
+			return err
+		}
+
+		if err := setupTheme(FlagColor); err != nil {
+			return err
+		}
+
+		// prompt: profile and region
+		if FlagPick {
+			return resolveProfileRegion(cmd.Context())
+		}
+
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -47,20 +125,174 @@ func Execute() {
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().BoolVarP(&FlagGroup, "group", "g", false, "group resource by service")
+	rootCmd.PersistentFlags().StringVarP(&FlagOutput, "output", "o", OutputText, "output format: text, json, ndjson, csv or raw")
+	rootCmd.PersistentFlags().StringVar(&FlagColor, "color", ColorAuto, "when to color output: auto, always or never, auto respects NO_COLOR")
+	rootCmd.PersistentFlags().BoolVar(&FlagNoPager, "no-pager", false, "do not pipe long output into $PAGER")
+	rootCmd.PersistentFlags().BoolVarP(&FlagPick, "pick", "p", false, "prompt for the AWS profile and region first")
+	rootCmd.PersistentFlags().StringVar(&FlagProfile, "profile", "", "AWS shared config profile")
+	rootCmd.PersistentFlags().StringVar(&FlagRegion, "region", "", "AWS region")
+	rootCmd.PersistentFlags().StringVar(&FlagRoleARN, "role-arn", "", "ARN of the IAM role to assume")
+	rootCmd.PersistentFlags().StringVar(&FlagEndpointURL, "endpoint-url", "", "custom endpoint URL, e.g. http://localhost:4566 for LocalStack")
+	rootCmd.PersistentFlags().BoolVar(&FlagRefresh, "refresh", false, "reload cached log group listings")
+	rootCmd.PersistentFlags().DurationVar(&FlagCacheTTL, "cache-ttl", time.Hour, "how long log group listings are cached for before being refreshed, 0 to disable")
+	rootCmd.PersistentFlags().StringVar(&FlagTZ, "tz", envOr("CWLR_TZ", "Local"), "time zone of entered and displayed times, e.g. UTC, Local, Asia/Singapore (env CWLR_TZ)")
 }
 
-// newClient attempts to create a new AWS Cloudwatch Logs Client
+// newClient attempts to create a new AWS Cloudwatch Logs Client, configured by the global flags
 func newClient(ctx context.Context, opts ...func(*config.LoadOptions) error) (*cloudwatchlogs.Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	cfg, err := loadConfig(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return cloudwatchlogs.NewFromConfig(cfg), nil
+	return cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+		if FlagEndpointURL != "" {
+			o.EndpointResolver = cloudwatchlogs.EndpointResolverFromURL(FlagEndpointURL)
+		}
+	}), nil
+}
+
+// loadConfig loads the shared AWS config with the profile, region and role given by the global flags
+func loadConfig(ctx context.Context, opts ...func(*config.LoadOptions) error) (aws.Config, error) {
+	if FlagProfile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(FlagProfile))
+	}
+	if FlagRegion != "" {
+		opts = append(opts, config.WithRegion(FlagRegion))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	return assumeRole(cfg), nil
+}
+
+// assumeRole returns the config with the credentials of the role given by flag, if any
+func assumeRole(cfg aws.Config) aws.Config {
+	if FlagRoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(newSTSClient(cfg), FlagRoleARN)
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg
+}
+
+// newSTSClient creates a new AWS STS Client, using the endpoint given by flag if any
+func newSTSClient(cfg aws.Config) *sts.Client {
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if FlagEndpointURL != "" {
+			o.EndpointResolver = sts.EndpointResolverFromURL(FlagEndpointURL)
+		}
+	})
 }
 
-func print(msg string, milli int64) {
-	dt := time.UnixMilli(milli).Format(time.RFC3339)
+// envOr returns the value of the environment variable, otherwise the fallback
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+// zoneName returns a short name of the time zone for display
+func zoneName() string {
+	if location == time.Local {
+		name, _ := time.Now().Zone()
+		return name
+	}
+
+	return location.String()
+}
+
+// jsonMode returns how JSON messages are formatted, set from FlagJSONPretty and FlagJSONCompact
+func jsonMode() string {
+	switch {
+	case FlagJSONPretty:
+		return JSONPretty
+	case FlagJSONCompact:
+		return JSONCompact
+	}
+
+	return JSONNone
+}
+
+// addFilterFlags adds the flags which format JSON messages and filter the events after they are retrieved
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&FlagJSONPretty, "json-pretty", false, "indent and color JSON messages")
+	cmd.Flags().BoolVar(&FlagJSONCompact, "json-compact", false, "print JSON messages on a single line")
+	cmd.MarkFlagsMutuallyExclusive("json-pretty", "json-compact")
+	cmd.Flags().StringVar(&FlagWhere, "where", "", `only display events whose JSON message matches the expression, e.g. 'status >= 500 and path startswith "/api"'`)
+	cmd.Flags().StringVar(&FlagGrep, "grep", "", "only display events whose message matches the regular expression")
+	cmd.Flags().BoolVarP(&FlagIgnoreCase, "ignore-case", "i", false, "match --grep case insensitively")
+	cmd.Flags().BoolVar(&FlagInvert, "invert", false, "only display events whose message does not match --grep")
+	cmd.Flags().StringVar(&FlagMinLevel, "min-level", "", "only display events at or above the detected log level: trace, debug, info, warn, error or fatal")
+}
+
+// addDisplayFlags adds the flags which merge multiline events and extract fields, given the usage of --multiline
+func addDisplayFlags(cmd *cobra.Command, multilineUsage string) {
+	cmd.Flags().BoolVar(&FlagMultiline, "multiline", false, multilineUsage)
+	cmd.Flags().StringVar(&FlagMultilineStart, "multiline-start", "", "regular expression matching the first line of events, implies --multiline")
+	cmd.Flags().StringSliceVar(&FlagFields, "fields", nil, "comma separated dot paths extracted from JSON messages, e.g. level,msg,req.id")
+}
+
+// eventFilters returns the filters of the events and the highlights of their messages given by the global flags
+func eventFilters() ([]func(LogEvent) bool, []highlight, error) {
+	var filters []func(LogEvent) bool
+	var highlights []highlight
+
+	if FlagWhere != "" {
+		f, err := whereFilter(FlagWhere)
+		if err != nil {
+			return nil, nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	switch {
+	case FlagGrep != "":
+		expr := FlagGrep
+		if FlagIgnoreCase {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --grep: %w", err)
+		}
+
+		// the flag is captured, the filter may outlive its value
+		invert := FlagInvert
+		filters = append(filters, func(e LogEvent) bool {
+			return re.MatchString(strings.TrimRight(e.Message, "\r\n")) != invert
+		})
+
+		// nothing is matched in the events of an inverted grep
+		if !invert {
+			highlights = append(highlights, highlight{re: re, role: roleMatch})
+		}
+	case FlagIgnoreCase || FlagInvert:
+		return nil, nil, errors.New("--ignore-case and --invert require --grep")
+	}
+
+	if FlagMinLevel != "" {
+		if err := validateLevel(FlagMinLevel); err != nil {
+			return nil, nil, fmt.Errorf("invalid --min-level: %w", err)
+		}
+		filters = append(filters, minLevelFilter(FlagMinLevel))
+	}
+
+	return filters, highlights, nil
+}
+
+// isInteractive reports whether stdin is attached to a terminal, i.e. prompts can be shown
+var isInteractive = func() bool {
+	return readline.IsTerminal(int(os.Stdin.Fd()))
+}
 
-	fmt.Printf("%s: %s", Cyan(dt), Green(msg))
+// errMissingFlag is returned when a required value is neither provided by flag nor can be prompted for
+func errMissingFlag(name string) error {
+	return fmt.Errorf("--%s is required when stdin is not a terminal", name)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// executeCommand runs cwlr non-interactively against the fake with the given arguments,
+// returning what is written to stdout and stderr
+func executeCommand(t *testing.T, fake *fakeLogs, args ...string) (string, string, error) {
+	t.Helper()
+
+	return execute(t, fake, false, args...)
+}
+
+// executeInteractive executes the command as if stdin is a terminal, the prompts must be replaced by the test
+func executeInteractive(t *testing.T, fake *fakeLogs, args ...string) (string, string, error) {
+	t.Helper()
+
+	return execute(t, fake, true, args...)
+}
+
+func execute(t *testing.T, fake *fakeLogs, interactive bool, args ...string) (string, string, error) {
+	t.Helper()
+
+	origAPI, origInteractive := newLogsAPI, isInteractive
+	t.Cleanup(func() {
+		newLogsAPI, isInteractive = origAPI, origInteractive
+		resetFlags(rootCmd)
+	})
+
+	useTempCacheDir(t)
+
+	newLogsAPI = func(ctx context.Context) (LogsAPI, error) {
+		return fake, nil
+	}
+	isInteractive = func() bool {
+		return interactive
+	}
+
+	// flags keep their values between executions
+	resetFlags(rootCmd)
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs(args)
+
+	err := rootCmd.ExecuteContext(context.Background())
+
+	return stdout.String(), stderr.String(), err
+}
+
+// resetFlags restores the flags of the command and its sub commands to their defaults
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			_ = v.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+
+	for _, it := range c.Commands() {
+		resetFlags(it)
+	}
+}
+
+// useTempCacheDir keeps the cache of the test within a temporary directory
+func useTempCacheDir(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	orig := userCacheDir
+	t.Cleanup(func() {
+		userCacheDir = orig
+	})
+
+	userCacheDir = func() (string, error) {
+		return dir, nil
+	}
+}
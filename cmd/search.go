@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,6 +17,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	FlagConcurrency int
+	FlagLimit       int
+)
+
 // searchCmd represents the search command
 var searchCmd = &cobra.Command{
 	Use:   "search",
@@ -23,135 +31,229 @@ var searchCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringSliceVar(&FlagLogGroups, "log-group", nil, "log group names or glob patterns to search (repeatable), skips the log group prompt")
+	searchCmd.Flags().BoolVar(&FlagPaged, "paged", false, "display a page of events at a time, prompting for more")
+	searchCmd.Flags().IntVar(&FlagPageSize, "page-size", 50, "number of events per page when paged")
+	searchCmd.Flags().BoolVar(&FlagShowStream, "show-stream", false, "display the full log stream name instead of a short label")
+	searchCmd.Flags().BoolVar(&FlagShowIngestion, "show-ingestion", false, "display the ingestion time of each event")
+	searchCmd.Flags().BoolVar(&FlagShowID, "show-id", false, "display the ID of each event")
+	searchCmd.Flags().IntVar(&FlagLimit, "limit", 0, "only display the first N matching events")
+	searchCmd.Flags().IntVar(&FlagConcurrency, "concurrency", 4, "maximum number of log groups searched concurrently")
+	searchCmd.Flags().StringVar(&FlagPattern, "pattern", "", "filter pattern, skips the filter pattern prompt")
+	searchCmd.Flags().StringVar(&FlagStart, "start", "", "start time, e.g. 15m, 2h ago, yesterday 09:00 or RFC3339, skips the start prompt")
+	searchCmd.Flags().StringVar(&FlagEnd, "end", "", "end time, e.g. now, 1h ago or RFC3339, skips the end prompt")
+	addFilterFlags(searchCmd)
+	addDisplayFlags(searchCmd, "merge continuation lines such as stack traces into the preceding event, only the lines matching --pattern are merged")
 }
 
 func excecuteSearch(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
 	// init cwl client
-	client, err := newClient(ctx)
+	client, err := newLogsAPI(ctx)
 	if err != nil {
 		return err
 	}
 
-	// get cloudwatch log groups
-	logGroups, err := getLogGroups(ctx, client)
+	if FlagConcurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+
+	if err := validatePaged(); err != nil {
+		return err
+	}
+
+	if err := validateFields(FlagFields); err != nil {
+		return err
+	}
+
+	filters, highlights, err := eventFilters()
 	if err != nil {
 		return err
 	}
 
-	// prompt: log group
-	var selLogGroup string
-	if FlagGroup {
-		selLogGroup, err = promptLogGroupWithGrouping(logGroups)
-	} else {
-		selLogGroup, err = promptLogGroup(logGroups)
+	multiline, multilineStart, err := multilineOptions()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case FlagLimit < 0:
+		return errors.New("--limit must not be negative")
+	case FlagPaged && FlagLimit > 0:
+		return errors.New("--paged cannot be combined with --limit")
 	}
+
+	// log groups
+	selLogGroups, err := resolveLogGroups(ctx, client, cmd.ErrOrStderr())
 	if err != nil {
 		return err
 	}
 
-	// prompt: filter pattern
-	pattern, err := promptPattern()
+	// filter pattern
+	pattern, err := resolvePattern(cmd)
 	if err != nil {
 		return err
 	}
 
-	// prompt: start date
-	start, err := promptDateTime("Start")
+	// highlight the terms of the filter pattern in the messages
+	highlights = append(highlights, patternHighlights(pattern)...)
+
+	// start date
+	start, err := resolveDateTime(cmd, "start", FlagStart, "Start")
 	if err != nil {
 		return err
 	}
 
-	// prompt: end date
-	end, err := promptDateTime("End")
+	// end date
+	end, err := resolveDateTime(cmd, "end", FlagEnd, "End")
 	if err != nil {
 		return err
 	}
 
-	// query
-	logs, err := getFilteredLogs(ctx, client, selLogGroup, pattern, start, end)
+	// prompts of the paged mode cannot be displayed through a pager
+	out := newPager(cmd.OutOrStdout(), !FlagPaged)
+
+	p, err := newPrinter(out, FlagOutput, printOptions{
+		ShowGroup:      len(selLogGroups) > 1,
+		StreamLabel:    true,
+		ShowStream:     FlagShowStream,
+		ShowIngestion:  FlagShowIngestion,
+		ShowID:         FlagShowID,
+		JSON:           jsonMode(),
+		Fields:         FlagFields,
+		Filters:        filters,
+		Highlights:     highlights,
+		Multiline:      multiline,
+		MultilineStart: multilineStart,
+		Limit:          FlagLimit,
+		DetectLevel:    FlagMinLevel != "",
+	})
 	if err != nil {
 		return err
 	}
 
-	// display
-	for _, it := range logs {
-		print(*it.Message, *it.Timestamp)
+	// multiple log groups are merged in timestamp order as their pages arrive
+	if len(selLogGroups) > 1 {
+		if FlagPaged {
+			return errors.New("--paged is not supported with multiple log groups")
+		}
+
+		err := searchLogGroups(ctx, client, selLogGroups, pattern, start, end, FlagLimit, FlagConcurrency, p.Print)
+
+		return out.Close(closePrinter(p, err))
 	}
 
-	return nil
+	display := func(logs []types.FilteredLogEvent) error {
+		for _, it := range logs {
+			if err := p.Print(fromFilteredLogEvent(it, selLogGroups[0])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// query and display each page as it arrives, or as requested when paged
+	if FlagPaged {
+		err = pageFilteredLogs(ctx, client, selLogGroups[0], pattern, start, end, int32(FlagPageSize), display)
+	} else {
+		err = getFilteredLogs(ctx, client, selLogGroups[0], pattern, start, end, FlagLimit, display)
+	}
+
+	return out.Close(closePrinter(p, err))
 }
 
-func promptPattern() (string, error) {
-	prompt := promptui.Prompt{
-		Label: "Filter Pattern",
+// resolvePattern returns the filter pattern given by flag, otherwise prompts for it.
+// An empty pattern matches everything, hence it is not required when non-interactive.
+func resolvePattern(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("pattern") || !isInteractive() {
+		return FlagPattern, nil
 	}
 
-	return prompt.Run()
+	return promptPattern()
 }
 
-func promptDateTime(labelPrefix string) (*int64, error) {
-	validateDate := func(input string) error {
-		s := strings.TrimSpace(input)
-		if len(s) == 0 {
-			return nil
+// resolveDateTime returns the time given by flag, otherwise prompts for it, and echoes the parsed time.
+// The time range is optional, hence it is not required when non-interactive.
+func resolveDateTime(cmd *cobra.Command, flag, value, labelPrefix string) (*int64, error) {
+	// values without an offset are in the configured time zone
+	now := time.Now().In(location)
+
+	var t time.Time
+	switch {
+	case cmd.Flags().Changed(flag):
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
 		}
 
-		if _, err := time.Parse("2006-01-02", s); err != nil {
-			return errors.New("invalid date format")
+		var err error
+		t, err = parseDateTime(value, now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+	case !isInteractive():
+		return nil, nil
+	default:
+		var err error
+		t, err = promptDateTime(labelPrefix, now)
+		if err != nil {
+			return nil, err
 		}
 
-		return nil
+		if t.IsZero() {
+			return nil, nil
+		}
 	}
 
-	promptDate := promptui.Prompt{
-		Label:    labelPrefix + " Date (YYYY-MM-DD)",
-		Validate: validateDate,
-	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s\t%s\n", theme.Label(labelPrefix+":"), t.Format(time.RFC3339))
 
-	resultDate, err := promptDate.Run()
-	if err != nil {
-		return nil, err
-	}
-	dateString := strings.TrimSpace(resultDate)
+	m := t.UnixMilli()
+	return &m, nil
+}
 
-	if len(dateString) == 0 {
-		return nil, nil
+func promptPattern() (string, error) {
+	prompt := promptui.Prompt{
+		Label:     "Filter Pattern",
+		Templates: promptTemplates(),
 	}
 
-	validateTime := func(input string) error {
-		s := strings.TrimSpace(input)
-		if _, err := time.Parse("15:04:05", s); err != nil {
-			return errors.New("invalid time format")
+	return prompt.Run()
+}
+
+// promptDateTime prompts for an absolute or relative date time, returns zero time if left empty
+func promptDateTime(labelPrefix string, now time.Time) (time.Time, error) {
+	validate := func(input string) error {
+		if strings.TrimSpace(input) == "" {
+			return nil
 		}
 
-		return nil
+		_, err := parseDateTime(input, now)
+		return err
 	}
 
-	promptTime := promptui.Prompt{
-		Label:    labelPrefix + " Time (HH:MM:SS)",
-		Validate: validateTime,
+	prompt := promptui.Prompt{
+		Label:     labelPrefix + " [" + zoneName() + "] (" + dateTimeHint + ")",
+		Validate:  validate,
+		Templates: promptTemplates(),
 	}
 
-	resultTime, err := promptTime.Run()
+	result, err := prompt.Run()
 	if err != nil {
-		return nil, err
+		return time.Time{}, err
 	}
-	timeString := strings.TrimSpace(resultTime)
 
-	// parse and convert into unix time
-	d, _ := time.Parse(time.RFC3339, fmt.Sprintf("%sT%sZ", dateString, timeString))
-	m := d.UnixMilli()
+	if strings.TrimSpace(result) == "" {
+		return time.Time{}, nil
+	}
 
-	return &m, nil
+	return parseDateTime(result, now)
 }
 
-func getFilteredLogs(ctx context.Context, client *cloudwatchlogs.Client, logGroup, pattern string, start, end *int64) ([]types.FilteredLogEvent, error) {
-	// TODO: consider handling of pagination from CLI instead (e.g prompt for "more")
-
-	var logs []types.FilteredLogEvent
-
+// getFilteredLogs retrieves the events of the log group matching the pattern, calling fn for every page until it
+// returns an error. Pages are of up to limit events if positive, as no more are displayed.
+func getFilteredLogs(ctx context.Context, client LogsAPI, logGroup, pattern string, start, end *int64, limit int, fn func([]types.FilteredLogEvent) error) error {
 	var next *string
 	for {
 		out, err := client.FilterLogEvents(ctx, &cloudwatchlogs.FilterLogEventsInput{
@@ -160,12 +262,15 @@ func getFilteredLogs(ctx context.Context, client *cloudwatchlogs.Client, logGrou
 			StartTime:     start,
 			EndTime:       end,
 			NextToken:     next,
+			Limit:         pageLimit(limit),
 		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		logs = append(logs, out.Events...)
+		if err := fn(out.Events); err != nil {
+			return err
+		}
 
 		next = out.NextToken
 		if next == nil {
@@ -173,5 +278,289 @@ func getFilteredLogs(ctx context.Context, client *cloudwatchlogs.Client, logGrou
 		}
 	}
 
-	return logs, nil
+	return nil
+}
+
+// searchLogGroups retrieves the events of multiple log groups matching the pattern, calling fn for each event
+// merged in timestamp order until it returns an error. The log groups are searched in pages of up to limit events
+// if positive, with at most concurrency requests in flight at a time.
+func searchLogGroups(ctx context.Context, client LogsAPI, logGroups []string, pattern string, start, end *int64, limit, concurrency int, fn func(LogEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	defer func() {
+		// stop the searches which have not completed
+		cancel()
+		wg.Wait()
+	}()
+
+	client = &throttledLogsAPI{LogsAPI: client, sem: make(chan struct{}, concurrency)}
+
+	// events of each log group in timestamp order, followed by an error if the search failed
+	streams := make([]chan searchResult, len(logGroups))
+	for i, lg := range logGroups {
+		streams[i] = make(chan searchResult, searchBuffer)
+
+		wg.Add(1)
+		go func(lg string, ch chan<- searchResult) {
+			defer wg.Done()
+			defer close(ch)
+
+			send := func(r searchResult) error {
+				select {
+				case ch <- r:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			// the earliest events overall are amongst the earliest events of each log group
+			err := getFilteredLogs(ctx, client, lg, pattern, start, end, limit, func(logs []types.FilteredLogEvent) error {
+				for _, it := range logs {
+					if err := send(searchResult{event: fromFilteredLogEvent(it, lg)}); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+			if err != nil && ctx.Err() == nil {
+				_ = send(searchResult{err: fmt.Errorf("%s: %w", lg, err)})
+			}
+		}(lg, streams[i])
+	}
+
+	// the next event of each log group, nil once it has no more events
+	heads := make([]*LogEvent, len(streams))
+	advance := func(i int) error {
+		r, ok := <-streams[i]
+		switch {
+		case !ok:
+			heads[i] = nil
+		case r.err != nil:
+			return r.err
+		default:
+			heads[i] = &r.event
+		}
+
+		return nil
+	}
+
+	for i := range streams {
+		if err := advance(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		// the earliest of the next events, the first log group given wins a tie
+		next := -1
+		for i, it := range heads {
+			if it != nil && (next < 0 || it.Timestamp < heads[next].Timestamp) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		if err := fn(*heads[next]); err != nil {
+			return err
+		}
+
+		if err := advance(next); err != nil {
+			return err
+		}
+	}
+
+	// the searches stop early when the parent context is cancelled
+	return ctx.Err()
+}
+
+// searchBuffer is the number of events of each log group buffered ahead of the merge
+const searchBuffer = 100
+
+// searchResult is an event or the error of a log group search
+type searchResult struct {
+	event LogEvent
+	err   error
+}
+
+// throttledLogsAPI limits the number of FilterLogEvents requests in flight
+type throttledLogsAPI struct {
+	LogsAPI
+	sem chan struct{}
+}
+
+func (c *throttledLogsAPI) FilterLogEvents(ctx context.Context, params *cloudwatchlogs.FilterLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.sem }()
+
+	return c.LogsAPI.FilterLogEvents(ctx, params, optFns...)
+}
+
+// resolveLogGroups returns the log groups given by flag with glob patterns expanded, otherwise prompts for them.
+// The prompted log groups are reported to w.
+func resolveLogGroups(ctx context.Context, client LogsAPI, w io.Writer) ([]string, error) {
+	if len(FlagLogGroups) > 0 {
+		return expandLogGroups(ctx, client, FlagLogGroups)
+	}
+
+	if !isInteractive() {
+		return nil, errMissingFlag("log-group")
+	}
+
+	// get cloudwatch log groups
+	logGroups, err := getCachedLogGroups(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// prompt: log group
+	if FlagGroup {
+		lg, err := promptLogGroupWithGrouping(logGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{lg}, nil
+	}
+
+	// prompt: log groups
+	return promptLogGroups(w, logGroups)
+}
+
+// expandLogGroups replaces glob patterns (* and ?) with the matching log groups
+func expandLogGroups(ctx context.Context, client LogsAPI, patterns []string) ([]string, error) {
+	if !strings.ContainsAny(strings.Join(patterns, ""), "*?") {
+		return patterns, nil
+	}
+
+	// get cloudwatch log groups
+	logGroups, err := getCachedLogGroups(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var lg []string
+	exists := make(map[string]bool)
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?") {
+			if !exists[pattern] {
+				exists[pattern] = true
+				lg = append(lg, pattern)
+			}
+			continue
+		}
+
+		re := globToRegexp(pattern)
+
+		var matched bool
+		for _, it := range logGroups {
+			if !re.MatchString(it) {
+				continue
+			}
+
+			matched = true
+			if !exists[it] {
+				exists[it] = true
+				lg = append(lg, it)
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("no log group matches %q", pattern)
+		}
+	}
+
+	return lg, nil
+}
+
+// globToRegexp converts a glob pattern into an anchored regexp, where * also matches /
+func globToRegexp(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+
+	sb.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.MustCompile(sb.String())
+}
+
+// promptDone is the prompt item for ending a multiple selection
+const promptDone = "(done)"
+
+// promptLogGroups prompts repeatedly for log groups until done is selected, reporting the selection to w
+func promptLogGroups(w io.Writer, logGroups []string) ([]string, error) {
+	var selected []string
+
+	remaining := append([]string{}, logGroups...)
+	for len(remaining) > 0 {
+		items := remaining
+		if len(selected) > 0 {
+			items = append([]string{promptDone}, remaining...)
+		}
+
+		tmpl := &promptui.SelectTemplates{
+			Label:    fmt.Sprintf("Select Log Groups (%d selected)", len(selected)),
+			Active:   "{{ icon }} {{ . | underline | selected }}",
+			Inactive: "  {{ . }}",
+			Selected: `{{ "Log Group:" | label }}	{{ . }}`,
+			FuncMap:  theme.PromptFuncs(),
+		}
+
+		searcher := func(input string, index int) bool {
+			item := items[index]
+
+			label := strings.ToLower(item)
+			search := strings.ToLower(input)
+
+			return strings.Contains(label, search)
+		}
+
+		prompt := promptui.Select{
+			Size:         10,
+			Items:        items,
+			Templates:    tmpl,
+			Searcher:     searcher,
+			HideSelected: true,
+		}
+
+		idx, result, err := prompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("prompt failed %v", err)
+		}
+
+		if len(selected) > 0 && idx == 0 {
+			break
+		}
+
+		selected = append(selected, result)
+
+		// remove the selected item
+		for i, it := range remaining {
+			if it == result {
+				remaining = append(remaining[:i:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%s\t%s\n", theme.Label("Log Groups:"), strings.Join(selected, ", "))
+
+	return selected, nil
 }
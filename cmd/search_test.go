@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	fake := newFakeLogs(2)
+	fake.AddEvent("/app/api", "s1", 1000, "INFO started\n")
+	fake.AddEvent("/app/api", "s1", 2000, "ERROR timeout\n")
+	fake.AddEvent("/app/api", "s2", 3000, "ERROR refused\n")
+	fake.AddEvent("/app/api", "s2", 4000, "ERROR late\n")
+	fake.AddEvent("/app/api", "s1", 5000, "INFO done\n")
+
+	stdout, stderr, err := executeCommand(t, fake, "search",
+		"--log-group", "/app/api",
+		"--pattern", "ERROR",
+		"--start", "1970-01-01T00:00:01Z",
+		"--end", "1970-01-01T00:00:03Z",
+		"--output", "raw",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "ERROR timeout\nERROR refused\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if !strings.Contains(stderr, "Start:") || !strings.Contains(stderr, "End:") {
+		t.Errorf("expected the parsed time range to be echoed, got %q", stderr)
+	}
+}
+
+func TestSearchMultipleLogGroups(t *testing.T) {
+	fake := newFakeLogs(1)
+	fake.AddEvent("/app/api", "s1", 1000, "api 1")
+	fake.AddEvent("/app/worker", "s1", 2000, "worker 2")
+	fake.AddEvent("/app/api", "s1", 3000, "api 3")
+	fake.AddEvent("/app/web", "s1", 4000, "web 4")
+	fake.AddEvent("/other", "s1", 5000, "other 5")
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/*", "--output", "ndjson", "--concurrency", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var e jsonLogEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, e.LogGroupName+": "+e.Message)
+	}
+
+	want := []string{"/app/api: api 1", "/app/worker: worker 2", "/app/api: api 3", "/app/web: web 4"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSearchUnmatchedGlob(t *testing.T) {
+	fake := newFakeLogs(1)
+	fake.AddLogGroup("/app/api")
+
+	_, _, err := executeCommand(t, fake, "search", "--log-group", "/nope/*")
+	if err == nil || !strings.Contains(err.Error(), "no log group matches") {
+		t.Errorf("got %v, want no match error", err)
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	fake := newFakeLogs(2)
+	for i := 1; i <= 7; i++ {
+		fake.AddEvent("/app/api", "s1", int64(i*1000), fmt.Sprintf("%d\n", i))
+	}
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--limit", "3", "--output", "raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "1\n2\n3\n"; stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	if got := fake.calls["FilterLogEvents"]; got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+}
+
+func TestSearchShowMetadata(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "2022/10/01/[$LATEST]0123456789abcdef", 1000, "hello\n")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{want: "89abcdef 1970-01-01T00:00:01Z: hello\n"},
+		{args: []string{"--show-stream"}, want: "2022/10/01/[$LATEST]0123456789abcdef 1970-01-01T00:00:01Z: hello\n"},
+		{args: []string{"--show-ingestion", "--show-id"}, want: "89abcdef 1970-01-01T00:00:01Z ingested=1970-01-01T00:00:01Z id=0: hello\n"},
+	} {
+		args := append([]string{"search", "--log-group", "/app/api", "--tz", "UTC"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+	}
+}
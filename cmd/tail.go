@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/spf13/cobra"
+)
+
+// tailCmd represents the tail command
+var tailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Follow and display new logs in the Log Group or Log Stream",
+	RunE:  executeTail,
+}
+
+var FlagInterval time.Duration
+
+// tailLookback is how far behind the latest seen event each poll starts from,
+// so that events ingested late are still picked up
+const tailLookback = 30 * time.Second
+
+func init() {
+	rootCmd.AddCommand(tailCmd)
+
+	tailCmd.Flags().StringVar(&FlagLogGroup, "log-group", "", "log group name, skips the log group prompt")
+	tailCmd.Flags().StringVar(&FlagStream, "stream", "", "log stream name, skips the log stream prompt")
+	tailCmd.Flags().StringVar(&FlagStreamPrefix, "stream-prefix", "", "only list log streams starting with the prefix")
+	tailCmd.Flags().StringVar(&FlagPattern, "pattern", "", "filter pattern, skips the filter pattern prompt")
+	tailCmd.Flags().BoolVar(&FlagShowStream, "show-stream", false, "display the full log stream name instead of a short label")
+	tailCmd.Flags().BoolVar(&FlagShowIngestion, "show-ingestion", false, "display the ingestion time of each event")
+	tailCmd.Flags().BoolVar(&FlagShowID, "show-id", false, "display the ID of each event")
+	tailCmd.Flags().DurationVar(&FlagInterval, "interval", 2*time.Second, "polling interval")
+	addFilterFlags(tailCmd)
+}
+
+func executeTail(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if FlagInterval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+
+	filters, highlights, err := eventFilters()
+	if err != nil {
+		return err
+	}
+
+	// init cwl client
+	client, err := newLogsAPI(ctx)
+	if err != nil {
+		return err
+	}
+
+	// log group
+	selLogGroup, err := resolveLogGroup(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	// log stream, empty for all streams
+	selStream, err := resolveTailStream(ctx, client, selLogGroup)
+	if err != nil {
+		return err
+	}
+
+	// filter pattern
+	pattern, err := resolvePattern(cmd)
+	if err != nil {
+		return err
+	}
+
+	// highlight the terms of the filter pattern in the messages
+	highlights = append(highlights, patternHighlights(pattern)...)
+
+	// stop following on ctrl-c
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// a label distinguishes the log streams when following all of them
+	p, err := newPrinter(cmd.OutOrStdout(), FlagOutput, printOptions{
+		StreamLabel:   selStream == "",
+		ShowStream:    FlagShowStream,
+		ShowIngestion: FlagShowIngestion,
+		ShowID:        FlagShowID,
+		JSON:          jsonMode(),
+		Filters:       filters,
+		Highlights:    highlights,
+		DetectLevel:   FlagMinLevel != "",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = followLogs(ctx, client, selLogGroup, selStream, pattern, FlagInterval, func(it types.FilteredLogEvent) error {
+		return p.Print(fromFilteredLogEvent(it, selLogGroup))
+	})
+	if ctx.Err() != nil {
+		// interrupted by user
+		err = nil
+	}
+
+	return closePrinter(p, err)
+}
+
+// allStreams is the log stream prompt item for following the entire log group
+var allStreams = LogStream{Name: "(all streams)"}
+
+// resolveTailStream returns the log stream given by flag, otherwise prompts for it with the option of all streams.
+// Returns empty when following all streams.
+func resolveTailStream(ctx context.Context, client LogsAPI, logGroup string) (string, error) {
+	if FlagStream != "" {
+		return FlagStream, nil
+	}
+
+	if !isInteractive() {
+		return "", nil
+	}
+
+	// get log streams by log group
+	logStreams, err := getLogStreams(ctx, client, logGroup, FlagStreamPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	// prompt: log stream
+	sel, err := promptLogStream(append([]LogStream{allStreams}, logStreams...))
+	if err != nil {
+		return "", err
+	}
+
+	if sel == allStreams.Name {
+		return "", nil
+	}
+
+	return sel, nil
+}
+
+// followLogs polls for log events from now onwards until the context is cancelled,
+// calling fn once for every new event
+func followLogs(ctx context.Context, client LogsAPI, logGroup, logStream, pattern string, interval time.Duration, fn func(types.FilteredLogEvent) error) error {
+	var streams []string
+	if logStream != "" {
+		streams = []string{logStream}
+	}
+
+	floor := time.Now().UnixMilli()
+	latest := floor
+
+	// event id to timestamp of events already displayed
+	seen := make(map[string]int64)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		start := latest - tailLookback.Milliseconds()
+		if start < floor {
+			start = floor
+		}
+
+		var next *string
+		for {
+			out, err := client.FilterLogEvents(ctx, &cloudwatchlogs.FilterLogEventsInput{
+				LogGroupName:   aws.String(logGroup),
+				LogStreamNames: streams,
+				FilterPattern:  aws.String(pattern),
+				StartTime:      aws.Int64(start),
+				NextToken:      next,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, it := range out.Events {
+				if _, ok := seen[*it.EventId]; ok {
+					continue
+				}
+				seen[*it.EventId] = *it.Timestamp
+
+				if *it.Timestamp > latest {
+					latest = *it.Timestamp
+				}
+
+				if err := fn(it); err != nil {
+					return err
+				}
+			}
+
+			next = out.NextToken
+			if next == nil {
+				break
+			}
+		}
+
+		// forget events which are no longer within the polling window
+		for id, ts := range seen {
+			if ts < start {
+				delete(seen, id)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func TestFollowLogs(t *testing.T) {
+	fake := newFakeLogs(1)
+
+	// events before following are not displayed
+	now := time.Now().UnixMilli()
+	fake.AddEvent("/app/api", "s1", now-time.Minute.Milliseconds(), "old")
+	fake.AddEvent("/app/api", "s1", now+1000, "new 1")
+	fake.AddEvent("/app/api", "s2", now+2000, "new 2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var got []string
+	err := followLogs(ctx, fake, "/app/api", "", "", 10*time.Millisecond, func(it types.FilteredLogEvent) error {
+		got = append(got, *it.Message)
+
+		if len(got) == 2 {
+			fake.AddEvent("/app/api", "s1", now+3000, "new 3")
+		}
+
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+
+	// each event is displayed once, despite being returned by every poll
+	want := []string{"new 1", "new 2", "new 3"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestClosePrinter(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want error
+	}{
+		{err: nil, want: nil},
+		{err: errLimitReached, want: nil},
+		{err: errors.New("throttled"), want: errors.New("throttled")},
+	} {
+		var buf bytes.Buffer
+		p, err := newPrinter(&buf, "json", printOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Print(LogEvent{Message: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the output is terminated even if following the logs failed
+		err = closePrinter(p, tc.err)
+		if fmt.Sprint(err) != fmt.Sprint(tc.want) {
+			t.Errorf("got %v, want %v", err, tc.want)
+		}
+
+		if got := buf.String(); !strings.HasSuffix(got, "]\n") {
+			t.Errorf("%v: got %q, want terminated JSON", tc.err, got)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/chzyer/readline"
+	"github.com/logrusorgru/aurora"
+	"github.com/manifoldco/promptui"
+)
+
+// supported color modes
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var FlagColor string
+
+// roles of colored output
+const (
+	roleTimestamp = "timestamp"
+	roleMessage   = "message"
+	roleGroup     = "group"
+	roleLabel     = "label"
+	roleMatch     = "match"
+	rolePattern   = "pattern"
+	roleSelected  = "selected"
+
+	roleLevelTrace = "level-trace"
+	roleLevelDebug = "level-debug"
+	roleLevelInfo  = "level-info"
+	roleLevelWarn  = "level-warn"
+	roleLevelError = "level-error"
+	roleLevelFatal = "level-fatal"
+
+	roleJSONKey     = "json-key"
+	roleJSONString  = "json-string"
+	roleJSONNumber  = "json-number"
+	roleJSONLiteral = "json-literal"
+)
+
+// Theme is the palette of all colored output, mapping each role to a color
+type Theme struct {
+	enabled bool
+	au      aurora.Aurora
+	palette map[string]aurora.Color
+
+	// streams are the colors log streams are distinguished by
+	streams []aurora.Color
+}
+
+// theme is used by all colored output, set up by the global flags
+var theme = newTheme(false)
+
+func newTheme(enabled bool) *Theme {
+	return &Theme{
+		enabled: enabled,
+		au:      aurora.NewAurora(enabled),
+		palette: map[string]aurora.Color{
+			roleTimestamp: aurora.CyanFg,
+			roleMessage:   aurora.GreenFg,
+			roleGroup:     aurora.MagentaFg,
+			roleLabel:     aurora.FaintFm,
+			// highlights are reversed to stand out from messages colored by their level
+			roleMatch:   aurora.RedFg | aurora.BoldFm | aurora.ReverseFm,
+			rolePattern: aurora.YellowFg | aurora.BoldFm | aurora.ReverseFm,
+			// the item under the cursor of prompts
+			roleSelected: aurora.CyanFg,
+
+			roleLevelTrace: aurora.FaintFm,
+			roleLevelDebug: aurora.FaintFm,
+			roleLevelInfo:  aurora.GreenFg,
+			roleLevelWarn:  aurora.YellowFg,
+			roleLevelError: aurora.RedFg,
+			roleLevelFatal: aurora.RedFg | aurora.BoldFm,
+
+			roleJSONKey:     aurora.BlueFg,
+			roleJSONString:  aurora.GreenFg,
+			roleJSONNumber:  aurora.YellowFg,
+			roleJSONLiteral: aurora.MagentaFg,
+		},
+		// magenta is left out, being the color of log groups
+		streams: []aurora.Color{
+			aurora.YellowFg,
+			aurora.BlueFg,
+			aurora.RedFg,
+			aurora.CyanFg | aurora.BrightFg,
+			aurora.YellowFg | aurora.BrightFg,
+			aurora.BlueFg | aurora.BrightFg,
+		},
+	}
+}
+
+// Color returns s in the color of the role
+func (t *Theme) Color(role string, s string) string {
+	return t.au.Colorize(s, t.palette[role]).String()
+}
+
+// Timestamp, Message, Group and Label return s in the color of their role
+func (t *Theme) Timestamp(s string) string { return t.Color(roleTimestamp, s) }
+func (t *Theme) Message(s string) string   { return t.Color(roleMessage, s) }
+func (t *Theme) Group(s string) string     { return t.Color(roleGroup, s) }
+func (t *Theme) Label(s string) string     { return t.Color(roleLabel, s) }
+
+// Stream returns s in the color of the log stream, which is the same for every occurrence of the log stream
+func (t *Theme) Stream(name, s string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+
+	return t.au.Colorize(s, t.streams[h.Sum32()%uint32(len(t.streams))]).String()
+}
+
+// iconSelect marks the item under the cursor of prompts
+const iconSelect = "▸"
+
+// PromptFuncs returns the functions of prompt templates, where selected and label color by their role
+// and icon is the icon of the item under the cursor. The colors of promptui are removed when colors are disabled.
+func (t *Theme) PromptFuncs() template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, fn := range promptui.FuncMap {
+		funcs[name] = fn
+		if !t.enabled {
+			funcs[name] = fmt.Sprint
+		}
+	}
+
+	funcs["selected"] = func(v interface{}) string { return t.Color(roleSelected, fmt.Sprint(v)) }
+	funcs["label"] = func(v interface{}) string { return t.Label(fmt.Sprint(v)) }
+	funcs["icon"] = func() string { return t.Color(roleSelected, iconSelect) }
+
+	return funcs
+}
+
+// promptTemplates returns the templates of text prompts, the default ones of promptui are always colored
+func promptTemplates() *promptui.PromptTemplates {
+	return &promptui.PromptTemplates{
+		Prompt:  `{{ "?" | blue | bold }} {{ . | bold }}{{ ":" | bold }} `,
+		Valid:   `{{ "✔" | green | bold }} {{ . | bold }}{{ ":" | bold }} `,
+		Invalid: `{{ "✗" | red | bold }} {{ . | bold }}{{ ":" | bold }} `,
+		Success: `{{ . | faint }}{{ ":" | faint }} `,
+		FuncMap: theme.PromptFuncs(),
+	}
+}
+
+// setupTheme enables colors according to the mode and applies the palette overrides of CWLR_COLORS
+func setupTheme(mode string) error {
+	var enabled bool
+	switch mode {
+	case ColorAlways:
+		enabled = true
+	case ColorNever:
+		enabled = false
+	case ColorAuto:
+		// https://no-color.org
+		enabled = os.Getenv("NO_COLOR") == "" && readline.IsTerminal(int(os.Stdout.Fd()))
+	default:
+		return fmt.Errorf("invalid color mode %q, must be one of: %s, %s, %s", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+
+	t := newTheme(enabled)
+	if err := t.parsePalette(os.Getenv("CWLR_COLORS")); err != nil {
+		return fmt.Errorf("invalid CWLR_COLORS: %w", err)
+	}
+
+	theme = t
+
+	return nil
+}
+
+// colorNames are the names accepted in the palette
+var colorNames = map[string]aurora.Color{
+	"black":     aurora.BlackFg,
+	"red":       aurora.RedFg,
+	"green":     aurora.GreenFg,
+	"yellow":    aurora.YellowFg,
+	"blue":      aurora.BlueFg,
+	"magenta":   aurora.MagentaFg,
+	"cyan":      aurora.CyanFg,
+	"white":     aurora.WhiteFg,
+	"bright":    aurora.BrightFg,
+	"bold":      aurora.BoldFm,
+	"faint":     aurora.FaintFm,
+	"italic":    aurora.ItalicFm,
+	"underline": aurora.UnderlineFm,
+	"reverse":   aurora.ReverseFm,
+	"none":      0,
+}
+
+// parsePalette applies overrides in the form role=color[+color]:role=color, e.g. message=white:timestamp=yellow+bold
+func (t *Theme) parsePalette(s string) error {
+	for _, it := range strings.Split(s, ":") {
+		if strings.TrimSpace(it) == "" {
+			continue
+		}
+
+		role, value, ok := strings.Cut(it, "=")
+		role = strings.TrimSpace(role)
+		if _, exists := t.palette[role]; !ok || !exists {
+			return fmt.Errorf("unknown role %q", role)
+		}
+
+		var color aurora.Color
+		for _, name := range strings.Split(value, "+") {
+			c, ok := colorNames[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Errorf("unknown color %q of %s", name, role)
+			}
+
+			color |= c
+		}
+
+		t.palette[role] = color
+	}
+
+	return nil
+}
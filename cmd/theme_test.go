@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestColorMode(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, "hello\n")
+
+	for _, tc := range []struct {
+		args    []string
+		noColor string
+		colored bool
+	}{
+		{args: []string{"--color", "always"}, colored: true},
+		{args: []string{"--color", "never"}, colored: false},
+		// stdout of the test is not a terminal
+		{args: []string{"--color", "auto"}, colored: false},
+		{args: []string{}, colored: false},
+		// always takes precedence over NO_COLOR
+		{args: []string{"--color", "always"}, noColor: "1", colored: true},
+	} {
+		t.Setenv("NO_COLOR", tc.noColor)
+
+		args := append([]string{"read", "--log-group", "/app/api", "--stream", "s1"}, tc.args...)
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.Contains(stdout, "\x1b["); got != tc.colored {
+			t.Errorf("%v: got colored %v, want %v: %q", tc.args, got, tc.colored, stdout)
+		}
+	}
+
+	if _, _, err := executeCommand(t, fake, "read", "--color", "sometimes"); err == nil {
+		t.Error("expected error for invalid color mode")
+	}
+}
+
+func TestParsePalette(t *testing.T) {
+	th := newTheme(true)
+
+	if err := th.parsePalette("message=white:timestamp=yellow+bold"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := th.Message("m"), "\x1b[37mm\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got, want := th.Timestamp("t"), "\x1b[1;33mt\x1b[0m"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, invalid := range []string{"unknown=red", "message=pink", "message"} {
+		if err := newTheme(true).parsePalette(invalid); err == nil {
+			t.Errorf("%q: expected error", invalid)
+		}
+	}
+}
+
+func TestPromptFuncs(t *testing.T) {
+	const tmpl = `{{ icon }} {{ . | underline | selected }} {{ "Log Group:" | label }} {{ . | faint }}`
+
+	for _, tc := range []struct {
+		enabled bool
+		palette string
+		want    string
+	}{
+		{enabled: false, want: "▸ a Log Group: a"},
+		{enabled: false, palette: "selected=red", want: "▸ a Log Group: a"},
+		{enabled: true, want: "\x1b[36m▸\x1b[0m \x1b[36m\x1b[4ma\x1b[0m\x1b[0m \x1b[2mLog Group:\x1b[0m \x1b[2ma\x1b[0m"},
+		{enabled: true, palette: "selected=red:label=none", want: "\x1b[31m▸\x1b[0m \x1b[31m\x1b[4ma\x1b[0m\x1b[0m Log Group: \x1b[2ma\x1b[0m"},
+	} {
+		th := newTheme(tc.enabled)
+		if err := th.parsePalette(tc.palette); err != nil {
+			t.Fatal(err)
+		}
+
+		tpl, err := template.New("").Funcs(th.PromptFuncs()).Parse(tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sb strings.Builder
+		if err := tpl.Execute(&sb, "a"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := sb.String(); got != tc.want {
+			t.Errorf("enabled %v, palette %q: got %q, want %q", tc.enabled, tc.palette, got, tc.want)
+		}
+	}
+}
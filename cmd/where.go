@@ -0,0 +1,487 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// whereCond is a condition of a --where expression
+type whereCond interface {
+	match(e *whereEvent) bool
+}
+
+// whereEvent is an event evaluated by a --where expression, its message is decoded on first use
+type whereEvent struct {
+	LogEvent
+	obj    map[string]interface{}
+	parsed bool
+}
+
+// lookup returns the value at the dot path of the JSON message, or of the event for the @ prefixed names
+func (e *whereEvent) lookup(path string) (interface{}, bool) {
+	switch path {
+	case "@message":
+		return strings.TrimRight(e.Message, "\r\n"), true
+	case "@logStream":
+		return e.LogStreamName, true
+	case "@logGroup":
+		return e.LogGroupName, true
+	}
+
+	if !e.parsed {
+		e.parsed = true
+		e.obj, _ = parseJSONObject(e.Message)
+	}
+	if e.obj == nil {
+		return nil, false
+	}
+
+	return lookupJSONPath(e.obj, path)
+}
+
+// whereFilter returns a filter of events matching the expression, e.g. status >= 500 and path startswith "/api"
+func whereFilter(expr string) (func(LogEvent) bool, error) {
+	cond, err := parseWhere(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --where expression: %w", err)
+	}
+
+	return func(e LogEvent) bool {
+		return cond.match(&whereEvent{LogEvent: e})
+	}, nil
+}
+
+type (
+	orCond  struct{ l, r whereCond }
+	andCond struct{ l, r whereCond }
+	notCond struct{ c whereCond }
+	// existsCond matches when the path is present and not null
+	existsCond struct{ path string }
+	cmpCond    struct {
+		op   string
+		l, r whereOperand
+		re   *regexp.Regexp
+	}
+)
+
+func (c orCond) match(e *whereEvent) bool  { return c.l.match(e) || c.r.match(e) }
+func (c andCond) match(e *whereEvent) bool { return c.l.match(e) && c.r.match(e) }
+func (c notCond) match(e *whereEvent) bool { return !c.c.match(e) }
+
+func (c existsCond) match(e *whereEvent) bool {
+	v, ok := e.lookup(c.path)
+	return ok && v != nil
+}
+
+func (c cmpCond) match(e *whereEvent) bool {
+	// missing values equal null
+	l, _ := c.l.value(e)
+	r, _ := c.r.value(e)
+
+	switch c.op {
+	case "==":
+		return equalValues(l, r)
+	case "!=":
+		return !equalValues(l, r)
+	}
+
+	// the remaining operators never match null
+	if l == nil || r == nil {
+		return false
+	}
+
+	switch c.op {
+	case "=~":
+		return c.re.MatchString(fieldString(l))
+	case "!~":
+		return !c.re.MatchString(fieldString(l))
+	case "contains":
+		return strings.Contains(fieldString(l), fieldString(r))
+	case "startswith":
+		return strings.HasPrefix(fieldString(l), fieldString(r))
+	case "endswith":
+		return strings.HasSuffix(fieldString(l), fieldString(r))
+	}
+
+	n, ok := compareValues(l, r)
+	if !ok {
+		return false
+	}
+
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+
+	return false
+}
+
+// whereOperand is a path or a literal of a comparison
+type whereOperand interface {
+	value(e *whereEvent) (interface{}, bool)
+}
+
+type (
+	pathOperand    struct{ path string }
+	literalOperand struct{ v interface{} }
+)
+
+func (o pathOperand) value(e *whereEvent) (interface{}, bool)  { return e.lookup(o.path) }
+func (o literalOperand) value(*whereEvent) (interface{}, bool) { return o.v, true }
+
+// toFloat returns the value as a number, numeric strings included as numbers are often logged as strings
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// equalValues reports whether the values are equal, comparing numbers by value
+func equalValues(a, b interface{}) bool {
+	if n, ok := compareValues(a, b); ok {
+		return n == 0
+	}
+
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	}
+
+	return false
+}
+
+// compareValues returns the order of the values, false if they are neither both numbers nor both strings
+func compareValues(a, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+
+	x, aok := a.(string)
+	y, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(x, y), true
+	}
+
+	return 0, false
+}
+
+// kinds of tokens of a --where expression
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type whereToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// whereOps are the symbolic operators, longest first
+var whereOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "(", ")"}
+
+// tokenizeWhere splits the expression into tokens
+func tokenizeWhere(s string) ([]whereToken, error) {
+	var tokens []whereToken
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+			continue
+		case c == '"' || c == '\'':
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, whereToken{kind: tokString, text: sb.String(), pos: i})
+			i = j + 1
+			continue
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789.eE+-", s[j]) > -1 {
+				j++
+			}
+			if _, err := strconv.ParseFloat(s[i:j], 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", s[i:j], i+1)
+			}
+			tokens = append(tokens, whereToken{kind: tokNumber, text: s[i:j], pos: i})
+			i = j
+			continue
+		case isIdentByte(c) && c != '.' && c != '-':
+			j := i + 1
+			for j < len(s) && isIdentByte(s[j]) {
+				j++
+			}
+			tokens = append(tokens, whereToken{kind: tokIdent, text: s[i:j], pos: i})
+			i = j
+			continue
+		}
+
+		op := ""
+		for _, it := range whereOps {
+			if strings.HasPrefix(s[i:], it) {
+				op = it
+				break
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("unexpected %q at position %d", c, i+1)
+		}
+
+		tokens = append(tokens, whereToken{kind: tokOp, text: op, pos: i})
+		i += len(op)
+	}
+
+	return append(tokens, whereToken{kind: tokEOF, pos: len(s)}), nil
+}
+
+// isIdentByte reports whether c may be part of a path
+func isIdentByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.@-$", c) > -1
+}
+
+// whereParser is a recursive descent parser of:
+//
+//	or   = and { ("or" | "||") and }
+//	and  = not { ("and" | "&&") not }
+//	not  = ("not" | "!") not | "(" or ")" | cmp
+//	cmp  = path | operand op operand
+//
+// The keywords and, or, not, contains, startswith and endswith are case insensitive.
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// parseWhere parses the expression into a condition
+func parseWhere(s string) (whereCond, error) {
+	tokens, err := tokenizeWhere(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &whereParser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errUnexpected(t, "end of expression")
+	}
+
+	return cond, nil
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the next token if it is one of the operators or keywords, keywords are case insensitive
+func (p *whereParser) accept(texts ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp && t.kind != tokIdent {
+		return false
+	}
+
+	for _, it := range texts {
+		if t.text == it || t.kind == tokIdent && strings.EqualFold(t.text, it) {
+			p.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *whereParser) errUnexpected(t whereToken, want string) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf("expected %s at end of expression", want)
+	}
+
+	return fmt.Errorf("expected %s at position %d, got %q", want, t.pos+1, t.text)
+}
+
+func (p *whereParser) parseOr() (whereCond, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("or", "||") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orCond{l: l, r: r}
+	}
+
+	return l, nil
+}
+
+func (p *whereParser) parseAnd() (whereCond, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("and", "&&") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = andCond{l: l, r: r}
+	}
+
+	return l, nil
+}
+
+func (p *whereParser) parseNot() (whereCond, error) {
+	if p.accept("not", "!") {
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{c: c}, nil
+	}
+
+	if p.accept("(") {
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errUnexpected(p.peek(), `")"`)
+		}
+		return c, nil
+	}
+
+	return p.parseCmp()
+}
+
+// whereCmpOps are the comparison operators
+var whereCmpOps = []string{"==", "!=", "<", "<=", ">", ">=", "=~", "!~", "contains", "startswith", "endswith"}
+
+func (p *whereParser) parseCmp() (whereCond, error) {
+	start := p.peek()
+
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if !p.accept(whereCmpOps...) {
+		// a path on its own checks for its existence
+		if o, ok := l.(pathOperand); ok {
+			return existsCond{path: o.path}, nil
+		}
+		return nil, p.errUnexpected(t, "comparison operator after "+strconv.Quote(start.text))
+	}
+
+	rt := p.peek()
+	r, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	c := cmpCond{op: strings.ToLower(t.text), l: l, r: r}
+	if c.op == "=~" || c.op == "!~" {
+		lit, ok := r.(literalOperand)
+		s, isString := lit.v.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("expected a quoted regular expression at position %d", rt.pos+1)
+		}
+
+		if c.re, err = regexp.Compile(s); err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %v", rt.pos+1, err)
+		}
+	}
+
+	return c, nil
+}
+
+func (p *whereParser) parseOperand() (whereOperand, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokString:
+		p.next()
+		return literalOperand{v: t.text}, nil
+	case tokNumber:
+		p.next()
+		return literalOperand{v: json.Number(t.text)}, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			p.next()
+			return literalOperand{v: t.text == "true"}, nil
+		case "null":
+			p.next()
+			return literalOperand{v: nil}, nil
+		}
+
+		switch strings.ToLower(t.text) {
+		case "and", "or", "not", "contains", "startswith", "endswith":
+			return nil, p.errUnexpected(t, "a path or value")
+		}
+
+		p.next()
+		return pathOperand{path: t.text}, nil
+	}
+
+	return nil, p.errUnexpected(t, "a path or value")
+}
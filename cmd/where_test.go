@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWhereFilter(t *testing.T) {
+	msg := `{"status":503,"path":"/api/orders","user":{"id":"42","admin":false},"tags":["a","b"],"error":null}`
+
+	for _, tc := range []struct {
+		expr string
+		want bool
+	}{
+		{expr: `status >= 500 and path startswith "/api"`, want: true},
+		{expr: `status >= 500 && path startswith "/web"`, want: false},
+		{expr: `status < 500 or path endswith 'orders'`, want: true},
+		{expr: `status == 503`, want: true},
+		{expr: `status != 503`, want: false},
+		{expr: `user.id == 42`, want: true},
+		{expr: `user.id < "100"`, want: true},
+		{expr: `user.admin == false`, want: true},
+		{expr: `not user.admin == true`, want: true},
+		{expr: `!(status >= 500)`, want: false},
+		{expr: `tags.1 == "b"`, want: true},
+		{expr: `path =~ "^/api/(orders|items)$"`, want: true},
+		{expr: `path !~ "orders"`, want: false},
+		{expr: `path contains "ord"`, want: true},
+		{expr: `user.id`, want: true},
+		{expr: `user.name`, want: false},
+		{expr: `error`, want: false},
+		{expr: `error == null and missing == null`, want: true},
+		{expr: `missing > 1`, want: false},
+		{expr: `@message contains "orders"`, want: true},
+		{expr: `@logStream == "s1" and (status == 200 or status == 503)`, want: true},
+		{expr: `NOT status < 500 AND path StartsWith "/api" Or missing`, want: true},
+	} {
+		f, err := whereFilter(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+
+		if got := f(LogEvent{LogStreamName: "s1", Message: msg + "\n"}); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+
+	// paths of non JSON messages are missing
+	f, err := whereFilter(`level == "error" or @message contains "error"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f(LogEvent{Message: "an error occurred\n"}) {
+		t.Error("expected plain message to match @message")
+	}
+}
+
+func TestWhereParseError(t *testing.T) {
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{expr: ``, want: "empty expression"},
+		{expr: `status >=`, want: "expected a path or value at end of expression"},
+		{expr: `status >= 500 and`, want: "expected a path or value at end of expression"},
+		{expr: `(status >= 500`, want: `expected ")" at end of expression`},
+		{expr: `status >= 500 path`, want: `expected end of expression at position 15, got "path"`},
+		{expr: `path == "/api`, want: "unterminated string at position 9"},
+		{expr: `path =~ "("`, want: "invalid regular expression at position 9"},
+		{expr: `path =~ other`, want: "expected a quoted regular expression at position 9"},
+		{expr: `500 and status`, want: `expected comparison operator after "500" at position 5, got "and"`},
+		{expr: `status # 1`, want: `unexpected '#' at position 8`},
+	} {
+		_, err := whereFilter(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, want %q", tc.expr, err, tc.want)
+		}
+	}
+}
+
+func TestSearchWhere(t *testing.T) {
+	fake := newFakeLogs(10)
+	fake.AddEvent("/app/api", "s1", 1000, `{"status":200,"path":"/api/a"}`+"\n")
+	fake.AddEvent("/app/api", "s1", 2000, `{"status":500,"path":"/health"}`+"\n")
+	fake.AddEvent("/app/api", "s1", 3000, `{"status":502,"path":"/api/b"}`+"\n")
+	fake.AddEvent("/app/api", "s1", 4000, "not json\n")
+
+	stdout, _, err := executeCommand(t, fake, "search", "--log-group", "/app/api", "--pattern", "", "--output", "raw",
+		"--where", `status >= 500 and path startswith "/api"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"status":502,"path":"/api/b"}` + "\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	_, _, err = executeCommand(t, fake, "search", "--log-group", "/app/api", "--where", "status >=")
+	if err == nil || !strings.Contains(err.Error(), "invalid --where expression") {
+		t.Errorf("got %v, want invalid --where expression error", err)
+	}
+}
+
+func TestWhereLimit(t *testing.T) {
+	// the matching events follow a page of events which are filtered out, and are followed by another
+	fake := newFakeLogs(2)
+	for i, status := range []int{200, 200, 200, 200, 500, 501, 502, 200} {
+		fake.AddEvent("/app/api", "s1", int64(1000*(i+1)), fmt.Sprintf(`{"status":%d}`, status)+"\n")
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"search", "--log-group", "/app/api", "--limit", "2"},
+			want: `{"status":500}` + "\n" + `{"status":501}` + "\n",
+		},
+		{
+			args: []string{"read", "--log-group", "/app/api", "--stream", "s1", "--head", "2"},
+			want: `{"status":500}` + "\n" + `{"status":501}` + "\n",
+		},
+		{
+			args: []string{"read", "--log-group", "/app/api", "--stream", "s1", "--tail", "2"},
+			want: `{"status":501}` + "\n" + `{"status":502}` + "\n",
+		},
+	} {
+		args := append(tc.args, "--output", "raw", "--where", "status >= 500")
+		stdout, _, err := executeCommand(t, fake, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, stdout, tc.want)
+		}
+	}
+}